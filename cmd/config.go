@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+	"github.com/tsaikd/KDGoLib/cliutil/cobrather"
+	"github.com/tsaikd/go-raml-mocker/mocker"
+)
+
+// environment variable prefix of command line flags
+const envPrefix = "RAML_MOCKER_"
+
+// flagEnvVar return environment variable name of flag, e.g. proxyDialTimeout to RAML_MOCKER_PROXY_DIAL_TIMEOUT
+func flagEnvVar(name string) string {
+	runes := []rune(name)
+	words := []rune{}
+	for i, r := range runes {
+		if r == '-' {
+			words = append(words, '_')
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, '_')
+			}
+		}
+		words = append(words, unicode.ToUpper(r))
+	}
+	return envPrefix + string(words)
+}
+
+// isFlagSet return true if flag is set by command line or environment variable,
+// environment variables of scalar flags are read by viper, of string slice flags by flagStringSlice
+func isFlagSet(cmd *cobra.Command, name string) bool {
+	if cmd.Flags().Changed(name) {
+		return true
+	}
+	_, exist := os.LookupEnv(flagEnvVar(name))
+	return exist
+}
+
+// flagStringSlice return values of string slice flag, or comma separated values of its environment variable if flag not set,
+// cobrather binds environment variables of scalar flags only
+func flagStringSlice(cmd *cobra.Command, flag *cobrather.StringSliceFlag) []string {
+	if cmd.Flags().Changed(flag.Name) {
+		return flag.StringSlice()
+	}
+	env, exist := os.LookupEnv(flagEnvVar(flag.Name))
+	if !exist {
+		return flag.StringSlice()
+	}
+	values := []string{}
+	for _, value := range strings.Split(env, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// configFilePath return config file from flag, or discovered in RAML file directory
+func configFilePath() string {
+	if path := flagConfigFile.String(); path != "" {
+		return path
+	}
+	return mocker.FindConfigFile(flagFile.String())
+}
+
+// buildConfigs return config of each RAML API from flags, environment variables and config file,
+// in order of precedence, settings of API declared in config file take precedence over all
+func buildConfigs(cmd *cobra.Command) (confs []mocker.Config, err error) {
+	proxyTargets := []mocker.ProxyTarget{}
+	if flagProxyTargetsFile.String() != "" {
+		if proxyTargets, err = mocker.LoadProxyTargets(flagProxyTargetsFile.String()); err != nil {
+			return
+		}
+	}
+	for _, text := range flagStringSlice(cmd, flagProxyTargets) {
+		target, err := mocker.ParseProxyTarget(text)
+		if err != nil {
+			return nil, err
+		}
+		proxyTargets = append(proxyTargets, target)
+	}
+
+	delay, err := mocker.ParseDelay(flagDelay.String())
+	if err != nil {
+		return nil, mocker.ErrorConfigInvalidValue2.New(err, "delay", flagDelay.String())
+	}
+
+	conf := mocker.Config{
+		RAMLFile:                       flagFile.String(),
+		CheckRAMLVersion:               flagCheckRAMLVersion.Bool(),
+		CacheDir:                       flagCacheDir.String(),
+		Port:                           flagPort.Int64(),
+		PathPrefix:                     flagPathPrefix.String(),
+		TLSCert:                        flagTLSCert.String(),
+		TLSKey:                         flagTLSKey.String(),
+		TLSSelfSigned:                  flagTLSSelfSigned.Bool(),
+		CORSOrigins:                    flagStringSlice(cmd, flagCORSOrigins),
+		CORSMethods:                    flagStringSlice(cmd, flagCORSMethods),
+		CORSHeaders:                    flagStringSlice(cmd, flagCORSHeaders),
+		CORSCredentials:                flagCORSCredentials.Bool(),
+		CORSMaxAge:                     flagCORSMaxAge.Int64(),
+		Proxy:                          flagProxy.String(),
+		ProxyTargets:                   proxyTargets,
+		Resources:                      mocker.BuildResourcesMap(flagStringSlice(cmd, flagResources)),
+		AllowRequiredPropertyToBeEmpty: flagAllowRequiredPropertyToBeEmpty.Bool(),
+		Seed:                           flagSeed.Int64(),
+		Stateful:                       flagStateful.Bool(),
+		JournalSize:                    flagJournalSize.Int64(),
+		Strict:                         flagStrict.Bool(),
+		FixturesDir:                    flagFixtures.String(),
+		Record:                         flagRecord.Bool(),
+		Replay:                         flagReplay.Bool(),
+		Contract:                       flagContract.Bool(),
+		ProxyDialTimeout:               flagProxyDialTimeout.Int64(),
+		ProxyTimeout:                   flagProxyTimeout.Int64(),
+		Delay:                          delay,
+		AdminToken:                     flagAdminToken.String(),
+	}
+
+	path := configFilePath()
+	if path == "" {
+		return []mocker.Config{conf}, nil
+	}
+	fileConfig, err := mocker.LoadConfigFile(path)
+	if err != nil {
+		return
+	}
+	return fileConfig.Configs(conf, func(key string) bool {
+		return isFlagSet(cmd, key)
+	})
+}
+
+var configValidateModule = &cobrather.Module{
+	Use:   "validate",
+	Short: "Validate config file, command line flags and environment variables against RAML file",
+	Example: strings.TrimSpace(`
+go-raml-mocker config validate --ramlfile "api.raml"
+go-raml-mocker config validate --config "mocker.yaml"
+	`),
+	RunE: func(ctx context.Context, cmd *cobra.Command, args []string) error {
+		confs, err := buildConfigs(cmd)
+		if err != nil {
+			return err
+		}
+		for _, conf := range confs {
+			if err = mocker.ValidateConfig(conf); err != nil {
+				return err
+			}
+		}
+		if path := configFilePath(); path != "" {
+			fmt.Printf("config file %q is valid\n", path)
+		} else {
+			fmt.Println("config is valid, no config file found")
+		}
+		return nil
+	},
+}
+
+var configModule = &cobrather.Module{
+	Use:   "config",
+	Short: "Config file utilities",
+	Commands: []*cobrather.Module{
+		configValidateModule,
+	},
+}
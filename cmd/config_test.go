@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-mocker/mocker"
+)
+
+func Test_buildConfigsStringSliceEnv(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	require.NoError(os.Setenv(flagEnvVar("corsOrigin"), "https://a.example.com, https://b.example.com"))
+	defer os.Unsetenv(flagEnvVar("corsOrigin"))
+	require.NoError(os.Setenv(flagEnvVar("resource"), "/other"))
+	defer os.Unsetenv(flagEnvVar("resource"))
+
+	command := Module.MustNewRootCommand(context.Background(), nil)
+	require.NoError(command.ParseFlags([]string{
+		"--config", "../example/mocker-config.yaml",
+	}))
+
+	confs, err := buildConfigs(command)
+	require.NoError(err)
+	require.Len(confs, 1)
+	require.Equal([]string{"https://a.example.com", "https://b.example.com"}, confs[0].CORSOrigins)
+	// environment variable take precedence over resources in config file
+	require.Len(confs[0].Resources, 1)
+	require.True(confs[0].Resources["/other"])
+
+	// command line flag take precedence over environment variable
+	command = Module.MustNewRootCommand(context.Background(), nil)
+	require.NoError(command.ParseFlags([]string{
+		"--config", "../example/mocker-config.yaml",
+		"--corsOrigin", "https://c.example.com",
+	}))
+	confs, err = buildConfigs(command)
+	require.NoError(err)
+	require.Equal([]string{"https://c.example.com"}, confs[0].CORSOrigins)
+}
+
+func Test_configValidateModule(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	command := Module.MustNewRootCommand(context.Background(), nil)
+	command.SetArgs([]string{"config", "validate", "--config", "../example/mocker-config.yaml"})
+	require.NoError(command.Execute())
+	require.Equal("../example/mocker-config.yaml", configFilePath())
+
+	command = Module.MustNewRootCommand(context.Background(), nil)
+	command.SetArgs([]string{"config", "validate", "--config", "../example/not-exist.yaml"})
+	require.Error(command.Execute())
+	require.Equal("../example/not-exist.yaml", configFilePath())
+}
+
+func Test_buildConfigsDelay(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	buildDelay := func(args ...string) (time.Duration, error) {
+		command := Module.MustNewRootCommand(context.Background(), nil)
+		require.NoError(command.ParseFlags(args))
+		confs, err := buildConfigs(command)
+		if err != nil {
+			return 0, err
+		}
+		return confs[0].Delay, nil
+	}
+
+	// test delay of command line flag
+	delay, err := buildDelay("--ramlfile", "../example/multiple-responses.raml", "--delay", "150ms")
+	require.NoError(err)
+	require.Equal(150*time.Millisecond, delay)
+
+	// test delay of config file
+	delay, err = buildDelay("--config", "../example/mocker-config.yaml")
+	require.NoError(err)
+	require.Equal(10*time.Millisecond, delay)
+
+	// test invalid delay rejected the same way from flag and config file
+	for _, text := range []string{"200", "-1s", "soon"} {
+		_, err = buildDelay("--ramlfile", "../example/multiple-responses.raml", "--delay", text)
+		require.Error(err, text)
+		require.True(mocker.ErrorConfigInvalidValue2.Match(err), text)
+
+		require.Error(mocker.FileConfig{Delay: &text}.Validate(), text)
+	}
+}
@@ -11,28 +11,113 @@ import (
 
 // command line flags
 var (
+	flagConfigFile = &cobrather.StringFlag{
+		Name:      "config",
+		ShortHand: "c",
+		Usage:     "YAML or JSON config file with keys the same as flags, keep empty to use go-raml-mocker.yaml, .yml or .json in RAML file directory if existed",
+		EnvVar:    flagEnvVar("config"),
+	}
 	flagFile = &cobrather.StringFlag{
 		Name:      "ramlfile",
 		ShortHand: "f",
 		Default:   "api.raml",
 		Usage:     "Source RAML file or directory path",
+		EnvVar:    flagEnvVar("ramlfile"),
 	}
 	flagCheckRAMLVersion = &cobrather.BoolFlag{
-		Name:  "checkRAMLVersion",
-		Usage: "Check RAML Version",
+		Name:   "checkRAMLVersion",
+		Usage:  "Check RAML Version",
+		EnvVar: flagEnvVar("checkRAMLVersion"),
 	}
 	flagCacheDir = &cobrather.StringFlag{
-		Name:  "cache",
-		Usage: "Cache parsed RAML file in cache directory",
+		Name:   "cache",
+		Usage:  "Cache parsed RAML file in cache directory",
+		EnvVar: flagEnvVar("cache"),
 	}
 	flagPort = &cobrather.Int64Flag{
 		Name:    "port",
 		Default: 4000,
 		Usage:   "Mock web server listen port",
+		EnvVar:  flagEnvVar("port"),
+	}
+	flagPathPrefix = &cobrather.StringFlag{
+		Name:   "prefix",
+		Usage:  "Path prefix to mount RAML resources on, e.g. /billing, used to serve multiple RAML APIs on the same port",
+		EnvVar: flagEnvVar("prefix"),
 	}
 	flagProxy = &cobrather.StringFlag{
-		Name:  "proxy",
-		Usage: "Proxy for mock request to original server, used when only mock some of APIs in RAML, keep empty to disable, e.g. http://origin.backend.addr:port",
+		Name:   "proxy",
+		Usage:  "Proxy for mock request to original server, used when only mock some of APIs in RAML, keep empty to disable, e.g. http://origin.backend.addr:port",
+		EnvVar: flagEnvVar("proxy"),
+	}
+	flagProxyTargets = &cobrather.StringSliceFlag{
+		Name:  "proxyTarget",
+		Usage: "Proxy requests under path prefix to another server, e.g. /users=http://users.backend:8080",
+	}
+	flagProxyTargetsFile = &cobrather.StringFlag{
+		Name:   "proxyTargetsFile",
+		Usage:  "YAML or JSON file of proxy targets routed by path prefix, RAML resource or annotation, with headers and TLS options",
+		EnvVar: flagEnvVar("proxyTargetsFile"),
+	}
+	flagProxyDialTimeout = &cobrather.Int64Flag{
+		Name:    "proxyDialTimeout",
+		Default: 10,
+		Usage:   "Timeout in seconds of connecting to proxy server",
+		EnvVar:  flagEnvVar("proxyDialTimeout"),
+	}
+	flagProxyTimeout = &cobrather.Int64Flag{
+		Name:    "proxyTimeout",
+		Default: 30,
+		Usage:   "Timeout in seconds of waiting response headers from proxy server, response body is streamed without timeout",
+		EnvVar:  flagEnvVar("proxyTimeout"),
+	}
+	flagTLSCert = &cobrather.StringFlag{
+		Name:   "tls-cert",
+		Usage:  "TLS certificate file, listen on HTTPS with HTTP/2 if set with --tls-key",
+		EnvVar: flagEnvVar("tls-cert"),
+	}
+	flagTLSKey = &cobrather.StringFlag{
+		Name:   "tls-key",
+		Usage:  "TLS private key file of --tls-cert",
+		EnvVar: flagEnvVar("tls-key"),
+	}
+	flagTLSSelfSigned = &cobrather.BoolFlag{
+		Name:   "tls-self-signed",
+		Usage:  "Listen on HTTPS with HTTP/2 by in-memory self-signed certificate for localhost, ignored if --tls-cert is set",
+		EnvVar: flagEnvVar("tls-self-signed"),
+	}
+	flagCORSOrigins = &cobrather.StringSliceFlag{
+		Name:    "corsOrigin",
+		Default: []string{"*"},
+		Usage:   "Allowed origins of cross-origin requests, * to allow all origins",
+	}
+	flagCORSMethods = &cobrather.StringSliceFlag{
+		Name:  "corsMethod",
+		Usage: "Allowed methods of CORS preflight requests, keep empty to allow requested method",
+	}
+	flagCORSHeaders = &cobrather.StringSliceFlag{
+		Name:  "corsHeader",
+		Usage: "Allowed headers of CORS preflight requests, keep empty to allow requested headers",
+	}
+	flagCORSCredentials = &cobrather.BoolFlag{
+		Name:   "corsCredentials",
+		Usage:  "Allow credentials of cross-origin requests",
+		EnvVar: flagEnvVar("corsCredentials"),
+	}
+	flagCORSMaxAge = &cobrather.Int64Flag{
+		Name:   "corsMaxAge",
+		Usage:  "Seconds of CORS preflight response to be cached, keep 0 to not send Access-Control-Max-Age",
+		EnvVar: flagEnvVar("corsMaxAge"),
+	}
+	flagDelay = &cobrather.StringFlag{
+		Name:   "delay",
+		Usage:  "Delay of every mock response, e.g. 200ms, route overrides take precedence",
+		EnvVar: flagEnvVar("delay"),
+	}
+	flagAdminToken = &cobrather.StringFlag{
+		Name:   "adminToken",
+		Usage:  "Token required by administrative API in Authorization bearer or X-Mock-Admin-Token header, keep empty to disable",
+		EnvVar: flagEnvVar("adminToken"),
 	}
 	flagResources = &cobrather.StringSliceFlag{
 		Name:      "resource",
@@ -40,8 +125,51 @@ var (
 		Usage:     "Mock resources, keep empty to mock all found resources",
 	}
 	flagAllowRequiredPropertyToBeEmpty = &cobrather.BoolFlag{
-		Name:  "allowRequiredPropertyToBeEmpty",
-		Usage: "allow required property to be empty value, but still should be existed",
+		Name:   "allowRequiredPropertyToBeEmpty",
+		Usage:  "allow required property to be empty value, but still should be existed",
+		EnvVar: flagEnvVar("allowRequiredPropertyToBeEmpty"),
+	}
+	flagSeed = &cobrather.Int64Flag{
+		Name:   "seed",
+		Usage:  "Random seed for generating response data from RAML types when no example declared, keep 0 to use random seed",
+		EnvVar: flagEnvVar("seed"),
+	}
+	flagStateful = &cobrather.BoolFlag{
+		Name:   "stateful",
+		Usage:  "Keep created, updated and deleted records of collection resources in memory, seeded from RAML examples",
+		EnvVar: flagEnvVar("stateful"),
+	}
+	flagJournalSize = &cobrather.Int64Flag{
+		Name:    "journalSize",
+		Default: 1000,
+		Usage:   "Max number of requests kept in request journal",
+		EnvVar:  flagEnvVar("journalSize"),
+	}
+	flagStrict = &cobrather.BoolFlag{
+		Name:   "strict",
+		Usage:  "Refuse to start if response examples do not satisfy declared types, and respond 500 for such responses",
+		EnvVar: flagEnvVar("strict"),
+	}
+	flagFixtures = &cobrather.StringFlag{
+		Name:    "fixtures",
+		Default: "fixtures",
+		Usage:   "Directory of recorded proxy fixtures",
+		EnvVar:  flagEnvVar("fixtures"),
+	}
+	flagRecord = &cobrather.BoolFlag{
+		Name:   "record",
+		Usage:  "Record proxied requests and responses into fixtures directory",
+		EnvVar: flagEnvVar("record"),
+	}
+	flagReplay = &cobrather.BoolFlag{
+		Name:   "replay",
+		Usage:  "Serve recorded fixtures before RAML examples and proxy server",
+		EnvVar: flagEnvVar("replay"),
+	}
+	flagContract = &cobrather.BoolFlag{
+		Name:   "contract",
+		Usage:  "Validate proxied requests and responses against RAML file, report violations in log and /__mocker/contract",
+		EnvVar: flagEnvVar("contract"),
 	}
 )
 
@@ -52,28 +180,54 @@ var Module = &cobrather.Module{
 	Example: strings.TrimSpace(`
 go-raml-mocker --ramlfile "api.raml" --proxy "https://backend.example.com"
 go-raml-mocker --ramlfile "./raml/directory/path" --cache ".ramlcache" --proxy "https://backend.example.com" --resource "/mock/resource1" --resource "/mock/resource2"
+go-raml-mocker --ramlfile "api.raml" --proxy "https://gateway.example.com" --proxyTarget "/users=https://users.example.com"
+go-raml-mocker --ramlfile "api.raml" --tls-self-signed
+go-raml-mocker --config "mocker.yaml"
+go-raml-mocker --ramlfile "billing.raml" --prefix "/billing"
 	`),
 	Commands: []*cobrather.Module{
 		cobrather.VersionModule,
+		configModule,
 	},
-	Flags: []cobrather.Flag{
+	// settings flags are global, so config validate sees the same settings as mock server
+	GlobalFlags: []cobrather.Flag{
+		flagConfigFile,
 		flagFile,
 		flagCheckRAMLVersion,
 		flagCacheDir,
 		flagPort,
+		flagPathPrefix,
+		flagTLSCert,
+		flagTLSKey,
+		flagTLSSelfSigned,
+		flagCORSOrigins,
+		flagCORSMethods,
+		flagCORSHeaders,
+		flagCORSCredentials,
+		flagCORSMaxAge,
 		flagProxy,
+		flagProxyTargets,
+		flagProxyTargetsFile,
+		flagProxyDialTimeout,
+		flagProxyTimeout,
 		flagResources,
 		flagAllowRequiredPropertyToBeEmpty,
+		flagSeed,
+		flagStateful,
+		flagJournalSize,
+		flagStrict,
+		flagFixtures,
+		flagRecord,
+		flagReplay,
+		flagContract,
+		flagDelay,
+		flagAdminToken,
 	},
 	RunE: func(ctx context.Context, cmd *cobra.Command, args []string) error {
-		return mocker.Start(mocker.Config{
-			RAMLFile:                       flagFile.String(),
-			CheckRAMLVersion:               flagCheckRAMLVersion.Bool(),
-			CacheDir:                       flagCacheDir.String(),
-			Port:                           flagPort.Int64(),
-			Proxy:                          flagProxy.String(),
-			Resources:                      mocker.BuildResourcesMap(flagResources.StringSlice()),
-			AllowRequiredPropertyToBeEmpty: flagAllowRequiredPropertyToBeEmpty.Bool(),
-		})
+		confs, err := buildConfigs(cmd)
+		if err != nil {
+			return err
+		}
+		return mocker.Start(confs...)
 	},
 }
@@ -0,0 +1,411 @@
+package mocker
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/gin"
+)
+
+// errors
+var (
+	ErrorAdminProxyDisabled     = errutil.NewFactory("proxy server not configured")
+	ErrorAdminRouteNotFound2    = errutil.NewFactory("route %s %q not found")
+	ErrorAdminResourceNotFound1 = errutil.NewFactory("resource %q not found")
+	ErrorAdminInvalidDelay1     = errutil.NewFactory("invalid delay %q")
+	ErrorAdminInvalidStatus1    = errutil.NewFactory("invalid status code %d")
+	ErrorAdminUnauthorized      = errutil.NewFactory("admin token required")
+)
+
+// path prefix of administrative API, not conflicted with RAML resources
+const adminPrefix = "/__mocker"
+
+// client send admin token by bearer authorization or admin token header
+const (
+	headerAuthorization  = "Authorization"
+	headerMockAdminToken = "X-Mock-Admin-Token"
+	authorizationBearer  = "Bearer "
+)
+
+// route override of request is passed to response selection by gin context
+const contextKeyOverride = "mocker.override"
+
+// adminRoute is a route bound from RAML file
+type adminRoute struct {
+	Method   string         `json:"method"`
+	Resource string         `json:"resource"`
+	Proxy    bool           `json:"proxy"`
+	Override *routeOverride `json:"override,omitempty"`
+}
+
+// RouteOverride replace response selection of route without modifying RAML file,
+// status code and example name requested by client take precedence
+type RouteOverride struct {
+	Method   string `yaml:"method" json:"method" binding:"required"`
+	Resource string `yaml:"resource" json:"resource" binding:"required"`
+	Status   int    `yaml:"status" json:"status,omitempty"`
+	Example  string `yaml:"example" json:"example,omitempty"`
+	Delay    string `yaml:"delay" json:"delay,omitempty"`
+}
+
+// routeOverride is route override with parsed delay
+type routeOverride struct {
+	RouteOverride
+	delay time.Duration
+}
+
+// parseRouteOverride return route override with parsed delay
+func parseRouteOverride(override RouteOverride) (result routeOverride, err error) {
+	result.RouteOverride = override
+	result.Method = strings.ToUpper(override.Method)
+	result.Resource = toRAMLResource(override.Resource)
+	if override.Status != 0 && (override.Status < 100 || override.Status > 599) {
+		return result, ErrorAdminInvalidStatus1.New(nil, override.Status)
+	}
+	if result.delay, err = ParseDelay(override.Delay); err != nil {
+		return
+	}
+	return
+}
+
+// ParseDelay return duration of delay text, e.g. 200ms, empty text is no delay,
+// used for delay of command line flag, config file and route overrides
+func ParseDelay(text string) (time.Duration, error) {
+	if text == "" {
+		return 0, nil
+	}
+	delay, err := time.ParseDuration(text)
+	if err != nil || delay < 0 {
+		return 0, ErrorAdminInvalidDelay1.New(err, text)
+	}
+	return delay, nil
+}
+
+// proxyToggle enable or disable proxy mode of resource
+type proxyToggle struct {
+	Resource string `json:"resource" binding:"required"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// adminState is runtime state controlled by administrative API
+type adminState struct {
+	mutex           sync.RWMutex
+	proxies         map[string]bool
+	overrides       map[string]routeOverride
+	configOverrides map[string]routeOverride
+}
+
+// newAdminState parse route overrides in config once,
+// they are validated on loading config, so invalid one is only traced and skipped
+func newAdminState(configOverrides []RouteOverride) *adminState {
+	state := &adminState{
+		proxies:         map[string]bool{},
+		overrides:       map[string]routeOverride{},
+		configOverrides: map[string]routeOverride{},
+	}
+	for _, configOverride := range configOverrides {
+		override, err := parseRouteOverride(configOverride)
+		if err != nil {
+			errutil.Trace(err)
+			continue
+		}
+		key := routeKey(override.Method, override.Resource)
+		if _, exist := state.configOverrides[key]; !exist {
+			state.configOverrides[key] = override
+		}
+	}
+	return state
+}
+
+// routeKey return map key of route, resource can be RAML or gin path
+func routeKey(method string, resource string) string {
+	return strings.ToUpper(method) + " " + toRAMLResource(resource)
+}
+
+func newAdminRoute(method string, resource string) adminRoute {
+	return adminRoute{
+		Method:   strings.ToUpper(method),
+		Resource: toRAMLResource(resource),
+	}
+}
+
+// adminRoutes is bound routes of RAML document keyed by route key
+type adminRoutes map[string]adminRoute
+
+func newAdminRoutes(routes []adminRoute) adminRoutes {
+	result := adminRoutes{}
+	for _, route := range routes {
+		result[routeKey(route.Method, route.Resource)] = route
+	}
+	return result
+}
+
+func (t adminRoutes) has(method string, resource string) bool {
+	_, exist := t[routeKey(method, resource)]
+	return exist
+}
+
+func (t adminRoutes) hasResource(resource string) bool {
+	for _, route := range t {
+		if route.Resource == toRAMLResource(resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// listRoutes return bound routes with proxy toggles and overrides, sorted by resource and method
+func (t *adminState) listRoutes(bound adminRoutes) []adminRoute {
+	t.mutex.RLock()
+	routes := []adminRoute{}
+	for _, route := range bound {
+		route.Proxy = t.proxies[route.Resource]
+		routes = append(routes, route)
+	}
+	t.mutex.RUnlock()
+
+	for i, route := range routes {
+		if override, exist := t.override(route.Method, route.Resource); exist {
+			routes[i].Override = &override
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Resource != routes[j].Resource {
+			return routes[i].Resource < routes[j].Resource
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+func (t *adminState) setProxy(resource string, enabled bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if enabled {
+		t.proxies[toRAMLResource(resource)] = true
+	} else {
+		delete(t.proxies, toRAMLResource(resource))
+	}
+}
+
+func (t *adminState) isProxy(resource string) bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.proxies[toRAMLResource(resource)]
+}
+
+func (t *adminState) setOverride(override routeOverride) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.overrides[routeKey(override.Method, override.Resource)] = override
+}
+
+// override return route override set by administrative API, fallback to route override in config
+func (t *adminState) override(method string, resource string) (routeOverride, bool) {
+	key := routeKey(method, resource)
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if override, exist := t.overrides[key]; exist {
+		return override, true
+	}
+	override, exist := t.configOverrides[key]
+	return override, exist
+}
+
+func (t *adminState) resetOverrides() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.overrides = map[string]routeOverride{}
+}
+
+// reset clear proxy toggles and route overrides
+func (t *adminState) reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.proxies = map[string]bool{}
+	t.overrides = map[string]routeOverride{}
+}
+
+// applyOverride set route override into gin context and wait for delay,
+// request headers are not modified, so journal, validation and proxy see the request of client
+func (t *Mocker) applyOverride(c *gin.Context, methodName string, path string) {
+	delay := t.config.Delay
+	if override, exist := t.admin.override(methodName, path); exist {
+		c.Set(contextKeyOverride, override)
+		if override.delay > 0 {
+			delay = override.delay
+		}
+	}
+	waitDelay(c, delay)
+}
+
+// contextOverride return route override of request set by applyOverride
+func contextOverride(c *gin.Context) (override routeOverride, exist bool) {
+	value, exist := c.Get(contextKeyOverride)
+	if !exist {
+		return
+	}
+	override, exist = value.(routeOverride)
+	return
+}
+
+// waitDelay wait for delay, stop waiting if client cancel the request
+func waitDelay(c *gin.Context, delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.Request.Context().Done():
+	}
+}
+
+// requestedAdminToken return admin token from request headers
+func requestedAdminToken(c *gin.Context) string {
+	if token := c.Request.Header.Get(headerMockAdminToken); token != "" {
+		return token
+	}
+	authorization := c.Request.Header.Get(headerAuthorization)
+	if strings.HasPrefix(authorization, authorizationBearer) {
+		return strings.TrimSpace(strings.TrimPrefix(authorization, authorizationBearer))
+	}
+	return ""
+}
+
+// adminAuth reject administrative API requests without admin token in config
+func (t *Mocker) adminAuth(c *gin.Context) {
+	if t.config.AdminToken == "" {
+		return
+	}
+	token := requestedAdminToken(c)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(t.config.AdminToken)) != 1 {
+		abortAdminError(c, http.StatusUnauthorized, ErrorAdminUnauthorized.New(nil))
+		return
+	}
+}
+
+func abortAdminError(c *gin.Context, code int, err error) {
+	c.JSON(code, gin.H{
+		"error": err.Error(),
+	})
+	c.Abort()
+}
+
+// bindAdminRoutes bind administrative API routes
+func (t *Mocker) bindAdminRoutes(router gin.IRouter) {
+	group := router.Group(adminPrefix)
+	group.Use(t.adminAuth)
+
+	group.GET("/routes", func(c *gin.Context) {
+		c.JSON(http.StatusOK, t.admin.listRoutes(t.currentBinding().routes))
+	})
+
+	group.GET("/status", func(c *gin.Context) {
+		c.JSON(http.StatusOK, t.reloadStatus())
+	})
+
+	group.POST("/reload", func(c *gin.Context) {
+		if err := t.reload(); err != nil {
+			abortAdminError(c, http.StatusInternalServerError, err)
+			return
+		}
+		c.JSON(http.StatusOK, t.admin.listRoutes(t.currentBinding().routes))
+	})
+
+	group.PUT("/proxy", func(c *gin.Context) {
+		toggle := proxyToggle{}
+		if err := c.BindJSON(&toggle); err != nil {
+			abortAdminError(c, http.StatusBadRequest, err)
+			return
+		}
+		if !t.currentBinding().routes.hasResource(toggle.Resource) {
+			abortAdminError(c, http.StatusNotFound, ErrorAdminResourceNotFound1.New(nil, toggle.Resource))
+			return
+		}
+		if toggle.Enabled && !t.config.hasProxyTarget() {
+			abortAdminError(c, http.StatusBadRequest, ErrorAdminProxyDisabled.New(nil))
+			return
+		}
+		t.admin.setProxy(toggle.Resource, toggle.Enabled)
+		c.JSON(http.StatusOK, toggle)
+	})
+
+	group.GET("/proxy", func(c *gin.Context) {
+		resources := []string{}
+		for _, route := range t.admin.listRoutes(t.currentBinding().routes) {
+			if route.Proxy && (len(resources) < 1 || resources[len(resources)-1] != route.Resource) {
+				resources = append(resources, route.Resource)
+			}
+		}
+		c.JSON(http.StatusOK, resources)
+	})
+
+	group.GET("/overrides", func(c *gin.Context) {
+		overrides := []routeOverride{}
+		for _, route := range t.admin.listRoutes(t.currentBinding().routes) {
+			if route.Override != nil {
+				overrides = append(overrides, *route.Override)
+			}
+		}
+		c.JSON(http.StatusOK, overrides)
+	})
+
+	group.PUT("/overrides", func(c *gin.Context) {
+		input := RouteOverride{}
+		if err := c.BindJSON(&input); err != nil {
+			abortAdminError(c, http.StatusBadRequest, err)
+			return
+		}
+		if !t.currentBinding().routes.has(input.Method, input.Resource) {
+			abortAdminError(c, http.StatusNotFound, ErrorAdminRouteNotFound2.New(nil, input.Method, input.Resource))
+			return
+		}
+		override, err := parseRouteOverride(input)
+		if err != nil {
+			abortAdminError(c, http.StatusBadRequest, err)
+			return
+		}
+		t.admin.setOverride(override)
+		c.JSON(http.StatusOK, override)
+	})
+
+	group.DELETE("/overrides", func(c *gin.Context) {
+		t.admin.resetOverrides()
+		c.Status(http.StatusNoContent)
+	})
+
+	group.GET("/requests", func(c *gin.Context) {
+		c.JSON(http.StatusOK, t.Journal(JournalFilter{
+			Resource: c.Query("resource"),
+			Method:   c.Query("method"),
+		}))
+	})
+
+	group.DELETE("/requests", func(c *gin.Context) {
+		t.ClearJournal()
+		c.Status(http.StatusNoContent)
+	})
+
+	group.GET("/contract", func(c *gin.Context) {
+		c.JSON(http.StatusOK, t.contract.list())
+	})
+
+	group.DELETE("/contract", func(c *gin.Context) {
+		t.contract.clear()
+		c.Status(http.StatusNoContent)
+	})
+
+	// reset stateful records, proxy toggles and route overrides
+	group.DELETE("/state", func(c *gin.Context) {
+		t.currentBinding().store.reset()
+		t.admin.reset()
+		c.Status(http.StatusNoContent)
+	})
+}
@@ -1,14 +1,44 @@
 package mocker
 
+import (
+	"strings"
+	"time"
+
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
 // Config for mock server
 type Config struct {
 	RAMLFile                       string
 	CheckRAMLVersion               bool
 	CacheDir                       string
 	Port                           int64
+	PathPrefix                     string
 	Proxy                          string
+	ProxyTargets                   []ProxyTarget
 	Resources                      map[string]bool
 	AllowRequiredPropertyToBeEmpty bool
+	Seed                           int64
+	Stateful                       bool
+	JournalSize                    int64
+	Strict                         bool
+	FixturesDir                    string
+	Record                         bool
+	Replay                         bool
+	Contract                       bool
+	ProxyDialTimeout               int64
+	ProxyTimeout                   int64
+	TLSCert                        string
+	TLSKey                         string
+	TLSSelfSigned                  bool
+	CORSOrigins                    []string
+	CORSMethods                    []string
+	CORSHeaders                    []string
+	CORSCredentials                bool
+	CORSMaxAge                     int64
+	Delay                          time.Duration
+	AdminToken                     string
+	Overrides                      []RouteOverride
 }
 
 // BuildResourcesMap return resource map by resources string slice
@@ -22,4 +52,19 @@ func BuildResourcesMap(resources []string) map[string]bool {
 	return resmap
 }
 
-var config = &Config{}
+// checkValueOptions return options of parser to check values against RAML types
+func (t *Config) checkValueOptions() []parser.CheckValueOption {
+	return []parser.CheckValueOption{
+		parser.CheckValueOptionAllowIntegerToBeNumber(true),
+		parser.CheckValueOptionAllowRequiredPropertyToBeEmpty(t.AllowRequiredPropertyToBeEmpty),
+	}
+}
+
+// pathPrefix return normalized path prefix of mock server, e.g. "/billing", empty if served at root
+func (t *Config) pathPrefix() string {
+	prefix := strings.Trim(t.PathPrefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
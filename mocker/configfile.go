@@ -0,0 +1,279 @@
+package mocker
+
+import (
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/KDGoLib/futil"
+	"gopkg.in/yaml.v2"
+)
+
+// errors
+var (
+	ErrorReadConfigFile1      = errutil.NewFactory("read config file %q failed")
+	ErrorConfigInvalidValue2  = errutil.NewFactory("invalid config %q: %v")
+	ErrorConfigRouteNotFound2 = errutil.NewFactory("config override route %s %q not found")
+)
+
+// config file names discovered in directory of RAML file, in order of precedence
+var configFileNames = []string{
+	"go-raml-mocker.yaml",
+	"go-raml-mocker.yml",
+	"go-raml-mocker.json",
+}
+
+// FileConfig is content of YAML or JSON config file,
+// keys are the same as command line flags, setting not declared in file is nil,
+// multiple RAML APIs served in one process are declared in apis with settings overriding top-level ones
+type FileConfig struct {
+	RAMLFile                       *string         `yaml:"ramlfile"`
+	CheckRAMLVersion               *bool           `yaml:"checkRAMLVersion"`
+	CacheDir                       *string         `yaml:"cache"`
+	Port                           *int64          `yaml:"port"`
+	PathPrefix                     *string         `yaml:"prefix"`
+	TLSCert                        *string         `yaml:"tls-cert"`
+	TLSKey                         *string         `yaml:"tls-key"`
+	TLSSelfSigned                  *bool           `yaml:"tls-self-signed"`
+	CORSOrigins                    []string        `yaml:"corsOrigin"`
+	CORSMethods                    []string        `yaml:"corsMethod"`
+	CORSHeaders                    []string        `yaml:"corsHeader"`
+	CORSCredentials                *bool           `yaml:"corsCredentials"`
+	CORSMaxAge                     *int64          `yaml:"corsMaxAge"`
+	Proxy                          *string         `yaml:"proxy"`
+	ProxyTargets                   []ProxyTarget   `yaml:"targets"`
+	ProxyDialTimeout               *int64          `yaml:"proxyDialTimeout"`
+	ProxyTimeout                   *int64          `yaml:"proxyTimeout"`
+	Resources                      []string        `yaml:"resource"`
+	AllowRequiredPropertyToBeEmpty *bool           `yaml:"allowRequiredPropertyToBeEmpty"`
+	Seed                           *int64          `yaml:"seed"`
+	Stateful                       *bool           `yaml:"stateful"`
+	JournalSize                    *int64          `yaml:"journalSize"`
+	Strict                         *bool           `yaml:"strict"`
+	FixturesDir                    *string         `yaml:"fixtures"`
+	Record                         *bool           `yaml:"record"`
+	Replay                         *bool           `yaml:"replay"`
+	Contract                       *bool           `yaml:"contract"`
+	Delay                          *string         `yaml:"delay"`
+	AdminToken                     *string         `yaml:"adminToken"`
+	Overrides                      []RouteOverride `yaml:"overrides"`
+	APIs                           []FileConfig    `yaml:"apis"`
+}
+
+// FindConfigFile return path of config file in directory of RAML file, empty if not found
+func FindConfigFile(ramlFile string) string {
+	dir := filepath.Dir(ramlFile)
+	if futil.IsDir(ramlFile) {
+		dir = ramlFile
+	}
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if futil.IsExist(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+// LoadConfigFile return validated config of YAML or JSON file
+func LoadConfigFile(path string) (fileConfig FileConfig, err error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fileConfig, ErrorReadConfigFile1.New(err, path)
+	}
+	// JSON is parsed as YAML flow style
+	if err = yaml.Unmarshal(raw, &fileConfig); err != nil {
+		return fileConfig, ErrorReadConfigFile1.New(err, path)
+	}
+	fileConfig.resolvePaths(filepath.Dir(path))
+	if err = fileConfig.Validate(); err != nil {
+		return fileConfig, ErrorReadConfigFile1.New(err, path)
+	}
+	return fileConfig, nil
+}
+
+// resolvePaths make relative file paths in config file relative to directory of config file
+func (t *FileConfig) resolvePaths(dir string) {
+	resolvePath(dir, t.RAMLFile)
+	resolvePath(dir, t.CacheDir)
+	resolvePath(dir, t.TLSCert)
+	resolvePath(dir, t.TLSKey)
+	resolvePath(dir, t.FixturesDir)
+	for i := range t.ProxyTargets {
+		tlsOptions := &t.ProxyTargets[i].TLS
+		resolvePath(dir, &tlsOptions.CAFile)
+		resolvePath(dir, &tlsOptions.CertFile)
+		resolvePath(dir, &tlsOptions.KeyFile)
+	}
+	for i := range t.APIs {
+		t.APIs[i].resolvePaths(dir)
+	}
+}
+
+func resolvePath(dir string, path *string) {
+	if path != nil && *path != "" && !filepath.IsAbs(*path) {
+		*path = filepath.Join(dir, *path)
+	}
+}
+
+// Validate check values of config file without loading RAML file
+func (t FileConfig) Validate() (err error) {
+	if t.Delay != nil {
+		if _, err = ParseDelay(*t.Delay); err != nil {
+			return ErrorConfigInvalidValue2.New(err, "delay", *t.Delay)
+		}
+	}
+	for _, target := range t.ProxyTargets {
+		if target.URL == "" {
+			return ErrorProxyTargetURLEmpty1.New(nil, target.name())
+		}
+	}
+	for _, override := range t.Overrides {
+		if override.Method == "" || override.Resource == "" {
+			return ErrorConfigInvalidValue2.New(nil, "overrides", override)
+		}
+		if _, err = parseRouteOverride(override); err != nil {
+			return ErrorConfigInvalidValue2.New(err, "overrides", override)
+		}
+	}
+	for _, api := range t.APIs {
+		if len(api.APIs) > 0 {
+			return ErrorConfigInvalidValue2.New(nil, "apis", "nested apis not supported")
+		}
+		if err = api.Validate(); err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// Apply set values of config file into conf, except settings of keys already set by command line flags or environment
+func (t FileConfig) Apply(conf *Config, isSet func(key string) bool) (err error) {
+	applyString(&conf.RAMLFile, t.RAMLFile, isSet("ramlfile"))
+	applyBool(&conf.CheckRAMLVersion, t.CheckRAMLVersion, isSet("checkRAMLVersion"))
+	applyString(&conf.CacheDir, t.CacheDir, isSet("cache"))
+	applyInt64(&conf.Port, t.Port, isSet("port"))
+	applyString(&conf.PathPrefix, t.PathPrefix, isSet("prefix"))
+	applyString(&conf.TLSCert, t.TLSCert, isSet("tls-cert"))
+	applyString(&conf.TLSKey, t.TLSKey, isSet("tls-key"))
+	applyBool(&conf.TLSSelfSigned, t.TLSSelfSigned, isSet("tls-self-signed"))
+	applyStrings(&conf.CORSOrigins, t.CORSOrigins, isSet("corsOrigin"))
+	applyStrings(&conf.CORSMethods, t.CORSMethods, isSet("corsMethod"))
+	applyStrings(&conf.CORSHeaders, t.CORSHeaders, isSet("corsHeader"))
+	applyBool(&conf.CORSCredentials, t.CORSCredentials, isSet("corsCredentials"))
+	applyInt64(&conf.CORSMaxAge, t.CORSMaxAge, isSet("corsMaxAge"))
+	applyString(&conf.Proxy, t.Proxy, isSet("proxy"))
+	if t.ProxyTargets != nil && !isSet("proxyTarget") && !isSet("proxyTargetsFile") {
+		conf.ProxyTargets = t.ProxyTargets
+	}
+	applyInt64(&conf.ProxyDialTimeout, t.ProxyDialTimeout, isSet("proxyDialTimeout"))
+	applyInt64(&conf.ProxyTimeout, t.ProxyTimeout, isSet("proxyTimeout"))
+	if t.Resources != nil && !isSet("resource") {
+		conf.Resources = BuildResourcesMap(t.Resources)
+	}
+	applyBool(&conf.AllowRequiredPropertyToBeEmpty, t.AllowRequiredPropertyToBeEmpty, isSet("allowRequiredPropertyToBeEmpty"))
+	applyInt64(&conf.Seed, t.Seed, isSet("seed"))
+	applyBool(&conf.Stateful, t.Stateful, isSet("stateful"))
+	applyInt64(&conf.JournalSize, t.JournalSize, isSet("journalSize"))
+	applyBool(&conf.Strict, t.Strict, isSet("strict"))
+	applyString(&conf.FixturesDir, t.FixturesDir, isSet("fixtures"))
+	applyBool(&conf.Record, t.Record, isSet("record"))
+	applyBool(&conf.Replay, t.Replay, isSet("replay"))
+	applyBool(&conf.Contract, t.Contract, isSet("contract"))
+	if t.Delay != nil && !isSet("delay") {
+		if conf.Delay, err = ParseDelay(*t.Delay); err != nil {
+			return ErrorConfigInvalidValue2.New(err, "delay", *t.Delay)
+		}
+	}
+	applyString(&conf.AdminToken, t.AdminToken, isSet("adminToken"))
+	if t.Overrides != nil {
+		conf.Overrides = t.Overrides
+	}
+	return nil
+}
+
+// Configs return config of each RAML API in config file, settings of API take precedence over top-level settings,
+// settings of keys already set by command line flags or environment are kept for every API,
+// return only conf with values of config file applied if no API declared
+func (t FileConfig) Configs(conf Config, isSet func(key string) bool) (confs []Config, err error) {
+	if err = t.Apply(&conf, isSet); err != nil {
+		return
+	}
+	if len(t.APIs) < 1 {
+		return []Config{conf}, nil
+	}
+	for _, api := range t.APIs {
+		apiConf := conf
+		if err = api.Apply(&apiConf, isSet); err != nil {
+			return nil, err
+		}
+		confs = append(confs, apiConf)
+	}
+	return confs, nil
+}
+
+func applyString(dst *string, value *string, isSet bool) {
+	if value != nil && !isSet {
+		*dst = *value
+	}
+}
+
+func applyBool(dst *bool, value *bool, isSet bool) {
+	if value != nil && !isSet {
+		*dst = *value
+	}
+}
+
+func applyInt64(dst *int64, value *int64, isSet bool) {
+	if value != nil && !isSet {
+		*dst = *value
+	}
+}
+
+func applyStrings(dst *[]string, value []string, isSet bool) {
+	if value != nil && !isSet {
+		*dst = value
+	}
+}
+
+// ValidateConfig check config values and load RAML file to check resources and route overrides in config
+func ValidateConfig(conf Config) (err error) {
+	if _, err = conf.tlsConfig(); err != nil {
+		return
+	}
+	if conf.Proxy != "" {
+		if _, err = url.Parse(conf.Proxy); err != nil {
+			return ErrorProxyInvalidURL1.New(err, conf.Proxy)
+		}
+	}
+	for _, target := range conf.ProxyTargets {
+		if _, err = target.targetURL(); err != nil {
+			return
+		}
+		if _, err = target.tlsConfig(); err != nil {
+			return
+		}
+	}
+	for _, override := range conf.Overrides {
+		if _, err = parseRouteOverride(override); err != nil {
+			return
+		}
+	}
+
+	rootdoc, err := parseRAMLFile(&conf)
+	if err != nil {
+		return
+	}
+	for _, override := range conf.Overrides {
+		resource := rootdoc.Resources[toRAMLResource(override.Resource)]
+		if resource == nil {
+			return ErrorConfigRouteNotFound2.New(nil, override.Method, override.Resource)
+		}
+		if _, exist := resource.Methods[strings.ToLower(override.Method)]; !exist {
+			return ErrorConfigRouteNotFound2.New(nil, override.Method, override.Resource)
+		}
+	}
+	return nil
+}
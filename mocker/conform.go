@@ -0,0 +1,166 @@
+package mocker
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/gin"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+// errors
+var (
+	ErrorResponseNotConform          = errutil.NewFactory("response does not satisfy declared type")
+	ErrorResponseExamplesNotConform1 = errutil.NewFactory("%d response examples do not satisfy declared types")
+)
+
+// example name of generated response body
+const exampleNameGenerated = "(generated)"
+
+// responseViolation is a response example not satisfied declared type
+type responseViolation struct {
+	Method     string      `json:"method"`
+	Resource   string      `json:"resource"`
+	Code       int         `json:"code"`
+	MIMEType   string      `json:"mimetype"`
+	Example    string      `json:"example"`
+	Violations []violation `json:"violations"`
+}
+
+func (t responseViolation) String() string {
+	messages := []string{}
+	for _, item := range t.Violations {
+		messages = append(messages, item.Error())
+	}
+	return fmt.Sprintf("%s %s %d %s example %q: %s", t.Method, t.Resource, t.Code, t.MIMEType, t.Example, strings.Join(messages, "; "))
+}
+
+// checkResponseExamples check all examples and generated bodies of responses against declared types
+func checkResponseExamples(conf *Config, rootdoc parser.RootDocument) []responseViolation {
+	result := []responseViolation{}
+
+	ramlPaths := []string{}
+	for ramlPath := range rootdoc.Resources {
+		ramlPaths = append(ramlPaths, ramlPath)
+	}
+	sort.Strings(ramlPaths)
+
+	for _, ramlPath := range ramlPaths {
+		resource := rootdoc.Resources[ramlPath]
+		if resource == nil {
+			continue
+		}
+		methodNames := []string{}
+		for name := range resource.Methods {
+			methodNames = append(methodNames, name)
+		}
+		sort.Strings(methodNames)
+
+		for _, methodName := range methodNames {
+			method := resource.Methods[methodName]
+			if method == nil {
+				continue
+			}
+			for _, code := range statusCodes(method.Responses) {
+				response := method.Responses[parser.HTTPCode(code)]
+				if response == nil {
+					continue
+				}
+				for _, mimetype := range responseMIMETypes(response) {
+					body := response.Bodies[mimetype]
+					if body == nil {
+						continue
+					}
+					for _, name := range responseExampleNames(*body) {
+						value, err := responseExampleValue(*body, name, rootdoc.Types, conf.Seed)
+						if err != nil {
+							errutil.Trace(err)
+							continue
+						}
+						violations := checkResponseValue(conf, mimetype, *body, rootdoc.Types, value)
+						if len(violations) < 1 {
+							continue
+						}
+						result = append(result, responseViolation{
+							Method:     strings.ToUpper(methodName),
+							Resource:   ramlPath,
+							Code:       code,
+							MIMEType:   mimetype,
+							Example:    name,
+							Violations: violations,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// responseExampleNames return example names of body, empty name for single example
+func responseExampleNames(body parser.Body) []string {
+	if !body.Examples.IsEmpty() {
+		return exampleNames(body)
+	}
+	if body.Example.Value.Type != "" {
+		return []string{""}
+	}
+	return []string{exampleNameGenerated}
+}
+
+func responseExampleValue(body parser.Body, name string, types parser.APITypes, seed int64) (parser.Value, error) {
+	switch name {
+	case "":
+		return body.Example.Value, nil
+	case exampleNameGenerated:
+		return parser.NewValue(newGenerator(seed, types).generate(body.APIType))
+	}
+	if example := body.Examples[name]; example != nil {
+		return example.Value, nil
+	}
+	return parser.Value{}, ErrorExampleNotFound1.New(nil, name)
+}
+
+// checkResponseValue return violations of response value against declared body type,
+// only JSON and XML bodies with declared type are checked
+func checkResponseValue(conf *Config, mimetype string, body parser.Body, types parser.APITypes, value parser.Value) []violation {
+	if !isJSONMIMEType(mimetype) && !isXMLMIMEType(mimetype) {
+		return nil
+	}
+	if isXMLMIMEType(mimetype) && value.Type == parser.TypeString && isXMLDocument(value.String) {
+		return nil
+	}
+	apiType := body.APIType
+	if typeExpression(apiType) == "" && len(apiType.Properties.Slice()) < 1 && apiType.Items == nil {
+		return nil
+	}
+
+	validator := newValidator(types, conf)
+	validator.validateBody(locationResponseBody, apiType, value)
+	return validator.violations
+}
+
+// reportResponseExamples log response examples not satisfied declared types,
+// return error in strict mode
+func reportResponseExamples(conf *Config, rootdoc parser.RootDocument) error {
+	violations := checkResponseExamples(conf, rootdoc)
+	for _, item := range violations {
+		logger.Warnln(item)
+	}
+	if conf.Strict && len(violations) > 0 {
+		return ErrorResponseExamplesNotConform1.New(nil, len(violations))
+	}
+	return nil
+}
+
+func abortResponseNotConform(c *gin.Context, violations []violation) {
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":      ErrorResponseNotConform.New(nil).Error(),
+		"violations": violations,
+	})
+	c.Abort()
+}
@@ -0,0 +1,230 @@
+package mocker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tsaikd/gin"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+// max number of entries kept in contract report
+const contractReportSize = 1000
+
+// location of violation if request method is not declared
+const locationMethod = "method"
+
+// contractEntry is a proxied exchange not satisfied RAML declaration
+type contractEntry struct {
+	Time       time.Time   `json:"time"`
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`
+	Resource   string      `json:"resource"`
+	Status     int         `json:"status"`
+	Violations []violation `json:"violations"`
+}
+
+func (t contractEntry) String() string {
+	messages := []string{}
+	for _, item := range t.Violations {
+		messages = append(messages, item.Error())
+	}
+	return t.Method + " " + t.Path + " violates contract of " + t.Resource + ": " + strings.Join(messages, "; ")
+}
+
+// contractReport is a bounded list of contract violations found by proxy server
+type contractReport struct {
+	mutex   sync.RWMutex
+	entries []contractEntry
+}
+
+// contract violations of proxied exchanges
+func newContractReport() *contractReport {
+	return &contractReport{
+		entries: []contractEntry{},
+	}
+}
+
+func (t *contractReport) add(entry contractEntry) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.entries) >= contractReportSize {
+		t.entries = append(t.entries[:0], t.entries[len(t.entries)-contractReportSize+1:]...)
+	}
+	t.entries = append(t.entries, entry)
+}
+
+func (t *contractReport) list() []contractEntry {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return append([]contractEntry{}, t.entries...)
+}
+
+func (t *contractReport) clear() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.entries = []contractEntry{}
+}
+
+// reportContract check proxied exchange and record violations into contract report
+func (t *Mocker) reportContract(c *gin.Context, reqBody []byte, resp *http.Response, respBody []byte) {
+	ramlPath, violations := t.checkContract(c, reqBody, resp, respBody)
+	if len(violations) < 1 {
+		return
+	}
+	entry := contractEntry{
+		Time:       time.Now(),
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		Resource:   ramlPath,
+		Status:     resp.StatusCode,
+		Violations: violations,
+	}
+	logger.Warnln(entry)
+	t.contract.add(entry)
+}
+
+// checkContract validate proxied exchange against RAML method,
+// return empty resource if request path is not declared in RAML file
+func (t *Mocker) checkContract(c *gin.Context, reqBody []byte, resp *http.Response, respBody []byte) (ramlPath string, violations []violation) {
+	matcher := t.currentResourceMatcher()
+	ramlPath, params := matcher.match(c.Request.URL.Path)
+	if ramlPath == "" {
+		return
+	}
+	rootdoc := matcher.rootdoc
+	resource := rootdoc.Resources[ramlPath]
+	if resource == nil {
+		return
+	}
+	types := rootdoc.Types
+	validator := newValidator(types, t.config)
+
+	method, exist := resource.Methods[strings.ToLower(c.Request.Method)]
+	if !exist {
+		declared := []string{}
+		for name := range resource.Methods {
+			declared = append(declared, strings.ToUpper(name))
+		}
+		sort.Strings(declared)
+		validator.add(locationMethod, "", strings.Join(declared, ","), c.Request.Method, "method not declared")
+		return ramlPath, validator.violations
+	}
+	if method == nil {
+		method = &parser.Method{}
+	}
+
+	// request
+	for _, param := range resourceURIParameters(rootdoc, ramlPath) {
+		validator.validateURIText(*param, params[param.Name])
+	}
+	for _, header := range method.Headers.Slice() {
+		validator.validateHeader(locationHeader, c.Request.Header, *header)
+	}
+	requestBody := parser.Value{}
+	if body := selectRequestBody(c, method.Bodies); body != nil {
+		value, ok, err := exchangeBodyValue(c.ContentType(), c.Request.Header.Get("Content-Encoding"), reqBody, body.APIType, types)
+		if err != nil {
+			validator.add(locationBody, "", mimetypeWithoutParams(c.ContentType()), nil, err.Error())
+		} else if ok {
+			requestBody = value
+			validator.validateBody(locationBody, body.APIType, value)
+		}
+	}
+	for _, qp := range method.QueryParameters.Slice() {
+		validator.validateQueryParameter(c, *qp, requestBody)
+	}
+	for _, istrait := range []parser.IsTraits{resource.Is, method.Is} {
+		for _, trait := range istrait {
+			validator.validateTrait(c, *trait, requestBody)
+		}
+	}
+
+	// response
+	if len(method.Responses) < 1 {
+		return ramlPath, validator.violations
+	}
+	response := method.Responses[parser.HTTPCode(resp.StatusCode)]
+	if response == nil {
+		validator.add(locationStatus, "", formatText(statusCodes(method.Responses)), resp.StatusCode, "status code not declared")
+		return ramlPath, validator.violations
+	}
+	for _, header := range response.Headers.Slice() {
+		validator.validateHeader(locationResponseHeader, resp.Header, *header)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if body := response.Bodies[mimetypeWithoutParams(contentType)]; body != nil {
+		value, ok, err := exchangeBodyValue(contentType, resp.Header.Get("Content-Encoding"), respBody, body.APIType, types)
+		if err != nil {
+			validator.add(locationResponseBody, "", mimetypeWithoutParams(contentType), nil, err.Error())
+		} else if ok {
+			validator.validateBody(locationResponseBody, body.APIType, value)
+		}
+	} else if len(response.Bodies) > 0 && len(respBody) > 0 {
+		validator.add(locationResponseBody, "", strings.Join(responseMIMETypes(response), ","), contentType, "media type not declared")
+	}
+
+	return ramlPath, validator.violations
+}
+
+// exchangeBodyValue parse JSON or XML body of proxied exchange, body is decoded by content encoding first,
+// return false if body is empty or can not be validated
+func exchangeBodyValue(contentType string, contentEncoding string, data []byte, apiType parser.APIType, types parser.APITypes) (value parser.Value, ok bool, err error) {
+	if len(bytes.TrimSpace(data)) < 1 {
+		return value, false, nil
+	}
+	if data, ok, err = decodeContentEncoding(contentEncoding, data); !ok || err != nil {
+		return
+	}
+	switch {
+	case isJSONMIMEType(contentType):
+		var body interface{}
+		if err = json.Unmarshal(data, &body); err != nil {
+			return
+		}
+		value, err = parser.NewValue(body)
+		return value, err == nil, err
+	case isXMLMIMEType(contentType):
+		node, err := decodeXML(bytes.NewReader(data))
+		if err != nil {
+			return value, false, err
+		}
+		value, err = parser.NewValue(xmlNodeValue(types, apiType, node))
+		return value, err == nil, err
+	}
+	return value, false, nil
+}
+
+// decodeContentEncoding return body decoded by Content-Encoding header value,
+// return false if encoding is not supported, e.g. br
+func decodeContentEncoding(encoding string, data []byte) ([]byte, bool, error) {
+	var reader io.ReadCloser
+	var err error
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return data, true, nil
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(data))
+	case "deflate":
+		reader, err = zlib.NewReader(bytes.NewReader(data))
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer reader.Close()
+	if data, err = ioutil.ReadAll(reader); err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
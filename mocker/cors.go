@@ -0,0 +1,147 @@
+package mocker
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tsaikd/gin"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+const corsAnyOrigin = "*"
+
+// CORS headers
+const (
+	headerOrigin                        = "Origin"
+	headerAccessControlRequestMethod    = "Access-Control-Request-Method"
+	headerAccessControlRequestHeaders   = "Access-Control-Request-Headers"
+	headerAccessControlAllowOrigin      = "Access-Control-Allow-Origin"
+	headerAccessControlAllowMethods     = "Access-Control-Allow-Methods"
+	headerAccessControlAllowHeaders     = "Access-Control-Allow-Headers"
+	headerAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	headerAccessControlExposeHeaders    = "Access-Control-Expose-Headers"
+	headerAccessControlMaxAge           = "Access-Control-Max-Age"
+)
+
+// corsAllowOrigin return value of Access-Control-Allow-Origin for request origin, empty if origin not allowed,
+// all origins are allowed if no origin in config
+func (t *Config) corsAllowOrigin(origin string) string {
+	wildcard := len(t.CORSOrigins) < 1
+	for _, allowed := range t.CORSOrigins {
+		if allowed == corsAnyOrigin {
+			wildcard = true
+			continue
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	if !wildcard {
+		return ""
+	}
+	// wildcard is not allowed with credentials by browsers
+	if t.CORSCredentials {
+		return origin
+	}
+	return corsAnyOrigin
+}
+
+// isPreflight return true if request is CORS preflight request
+func isPreflight(req *http.Request) bool {
+	return req.Method == http.MethodOptions && req.Header.Get(headerAccessControlRequestMethod) != ""
+}
+
+// corsMiddleware apply CORS policy in config to cross-origin requests, preflight requests are answered directly
+func (t *Mocker) corsMiddleware(c *gin.Context) {
+	conf := t.config
+	origin := c.Request.Header.Get(headerOrigin)
+	if origin == "" {
+		return
+	}
+
+	allowOrigin := conf.corsAllowOrigin(origin)
+	if allowOrigin != corsAnyOrigin {
+		c.Writer.Header().Add("Vary", headerOrigin)
+	}
+	if allowOrigin == "" {
+		if isPreflight(c.Request) {
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+		return
+	}
+
+	c.Header(headerAccessControlAllowOrigin, allowOrigin)
+	if conf.CORSCredentials {
+		c.Header(headerAccessControlAllowCredentials, "true")
+	}
+
+	if !isPreflight(c.Request) {
+		return
+	}
+
+	if len(conf.CORSMethods) > 0 {
+		c.Header(headerAccessControlAllowMethods, strings.Join(conf.CORSMethods, ", "))
+	} else {
+		c.Header(headerAccessControlAllowMethods, c.Request.Header.Get(headerAccessControlRequestMethod))
+	}
+	if len(conf.CORSHeaders) > 0 {
+		c.Header(headerAccessControlAllowHeaders, strings.Join(conf.CORSHeaders, ", "))
+	} else if value := c.Request.Header.Get(headerAccessControlRequestHeaders); value != "" {
+		c.Header(headerAccessControlAllowHeaders, value)
+	}
+	if conf.CORSMaxAge > 0 {
+		c.Header(headerAccessControlMaxAge, strconv.FormatInt(conf.CORSMaxAge, 10))
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// isCORSHeader return true if header is set by CORS policy
+func isCORSHeader(name string) bool {
+	return strings.HasPrefix(http.CanonicalHeaderKey(name), "Access-Control-")
+}
+
+// exposeResponseHeaders expose response headers declared in RAML method to cross-origin requests
+func exposeResponseHeaders(c *gin.Context, method parser.Method) {
+	if c.Writer.Header().Get(headerAccessControlAllowOrigin) == "" {
+		return
+	}
+	if names := declaredResponseHeaders(method); len(names) > 0 {
+		c.Header(headerAccessControlExposeHeaders, strings.Join(names, ", "))
+	}
+}
+
+// declaredResponseHeaders return sorted names of response headers declared in all responses of method
+func declaredResponseHeaders(method parser.Method) []string {
+	exists := map[string]bool{}
+	names := []string{}
+	for _, response := range method.Responses {
+		if response == nil {
+			continue
+		}
+		for _, header := range response.Headers.Slice() {
+			name := http.CanonicalHeaderKey(header.Name)
+			if exists[name] || isCORSHeader(name) {
+				continue
+			}
+			exists[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resourceMethod return RAML method of request path matched in resource matcher
+func (t *resourceMatcher) resourceMethod(path string, methodName string) (method parser.Method, ok bool) {
+	ramlPath, _ := t.match(path)
+	resource := t.rootdoc.Resources[ramlPath]
+	if resource == nil {
+		return
+	}
+	if result := resource.Methods[strings.ToLower(methodName)]; result != nil {
+		return *result, true
+	}
+	return
+}
@@ -0,0 +1,227 @@
+package mocker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/KDGoLib/futil"
+	"github.com/tsaikd/gin"
+	"github.com/tsaikd/go-raml-parser/parser"
+	"gopkg.in/yaml.v2"
+)
+
+// errors
+var (
+	ErrorExampleNotFound1 = errutil.NewFactory("example %q not found")
+)
+
+// client can select named example by header or query parameter
+const (
+	headerMockExample = "X-Mock-Example"
+	queryMockExample  = "__example"
+)
+
+// exampleNames return sorted example names of body,
+// parser does not keep declaration order of examples, so sort them to be stable
+func exampleNames(body parser.Body) []string {
+	names := []string{}
+	for name := range body.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// exampleOrder is declaration order of example names in RAML file, keyed by exampleKey
+type exampleOrder map[string][]string
+
+func exampleKey(method string, resource string, code int, mimetype string) string {
+	return fmt.Sprintf("%s %s %d %s", strings.ToUpper(method), toRAMLResource(resource), code, mimetype)
+}
+
+// names return example names of body in declaration order,
+// names not found in RAML file, e.g. declared in resource types or included files, follow in sorted order
+func (t exampleOrder) names(key string, body parser.Body) []string {
+	names := []string{}
+	exists := map[string]bool{}
+	for _, name := range t[key] {
+		if example := body.Examples[name]; example != nil && !exists[name] {
+			exists[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, name := range exampleNames(body) {
+		if !exists[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// HTTP methods declared in RAML resource
+var ramlMethods = map[string]bool{
+	"get": true, "patch": true, "put": true, "post": true, "delete": true, "options": true, "head": true,
+}
+
+// loadExampleOrder return declaration order of examples in RAML file,
+// parser keeps examples in map, so RAML file is read again as ordered YAML on each build,
+// examples file included by !include is read too, but examples declared in libraries,
+// resource types and included resources are not found, they follow in sorted order by exampleOrder.names,
+// empty if RAML file is a directory or can not be read
+func loadExampleOrder(ramlFile string) exampleOrder {
+	order := exampleOrder{}
+	if ramlFile == "" || futil.IsDir(ramlFile) {
+		return order
+	}
+	root, err := readMapSlice(ramlFile)
+	if err != nil {
+		logger.Debugf("read example order of %q failed: %v", ramlFile, err)
+		return order
+	}
+	dir := filepath.Dir(ramlFile)
+
+	mediaTypes := []string{}
+	for _, item := range root {
+		if fmt.Sprint(item.Key) != "mediaType" {
+			continue
+		}
+		switch value := item.Value.(type) {
+		case string:
+			mediaTypes = append(mediaTypes, value)
+		case []interface{}:
+			for _, mediaType := range value {
+				mediaTypes = append(mediaTypes, fmt.Sprint(mediaType))
+			}
+		}
+	}
+
+	var visit func(resource string, node yaml.MapSlice)
+	visit = func(resource string, node yaml.MapSlice) {
+		for _, item := range node {
+			key := fmt.Sprint(item.Key)
+			value, _ := item.Value.(yaml.MapSlice)
+			switch {
+			case strings.HasPrefix(key, "/"):
+				visit(resource+key, value)
+			case ramlMethods[key]:
+				order.addMethod(key, resource, value, mediaTypes, dir)
+			}
+		}
+	}
+	visit("", root)
+	return order
+}
+
+// readMapSlice read YAML file as ordered map
+func readMapSlice(filename string) (result yaml.MapSlice, err error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	err = yaml.Unmarshal(raw, &result)
+	return
+}
+
+func (t exampleOrder) addMethod(method string, resource string, node yaml.MapSlice, mediaTypes []string, dir string) {
+	responses, _ := mapSliceValue(node, "responses").(yaml.MapSlice)
+	for _, item := range responses {
+		code := 0
+		if _, err := fmt.Sscan(fmt.Sprint(item.Key), &code); err != nil {
+			continue
+		}
+		response, _ := item.Value.(yaml.MapSlice)
+		bodies, _ := mapSliceValue(response, "body").(yaml.MapSlice)
+		if names := exampleNamesOf(bodies, dir); len(names) > 0 {
+			// body without media type uses default media types of RAML file
+			for _, mediaType := range mediaTypes {
+				t[exampleKey(method, resource, code, mediaType)] = names
+			}
+			continue
+		}
+		for _, body := range bodies {
+			node, _ := body.Value.(yaml.MapSlice)
+			if names := exampleNamesOf(node, dir); len(names) > 0 {
+				t[exampleKey(method, resource, code, fmt.Sprint(body.Key))] = names
+			}
+		}
+	}
+}
+
+// exampleNamesOf return example names of body node in declaration order,
+// examples can be included from file relative to dir
+func exampleNamesOf(body yaml.MapSlice, dir string) []string {
+	names := []string{}
+	examples, _ := mapSliceValue(body, "examples").(yaml.MapSlice)
+	if include, ok := mapSliceValue(body, "examples").(string); ok && include != "" {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(dir, include)
+		}
+		var err error
+		if examples, err = readMapSlice(include); err != nil {
+			logger.Debugf("read example order of %q failed: %v", include, err)
+		}
+	}
+	for _, item := range examples {
+		names = append(names, fmt.Sprint(item.Key))
+	}
+	return names
+}
+
+func mapSliceValue(node yaml.MapSlice, key string) interface{} {
+	for _, item := range node {
+		if fmt.Sprint(item.Key) == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+// requestedExampleName return example name from request header or query parameter
+func requestedExampleName(c *gin.Context) string {
+	if name := c.Request.Header.Get(headerMockExample); name != "" {
+		return name
+	}
+	if name, exist := c.GetQuery(queryMockExample); exist {
+		return name
+	}
+	return ""
+}
+
+// selectExample return the example value of body requested by client,
+// fallback to example of route override, then the first example of names,
+// generate random value from body type if there is no example
+func selectExample(c *gin.Context, body parser.Body, names []string, types parser.APITypes, seed int64) (value parser.Value, err error) {
+	if body.Examples.IsEmpty() {
+		if body.Example.Value.Type == "" {
+			return parser.NewValue(newGenerator(seed, types).generate(body.APIType))
+		}
+		return body.Example.Value, nil
+	}
+
+	name := requestedExampleName(c)
+	if override, exist := contextOverride(c); name == "" && exist {
+		name = override.Example
+	}
+	if name == "" {
+		name = names[0]
+	}
+
+	example, exist := body.Examples[name]
+	if !exist || example == nil {
+		return value, ErrorExampleNotFound1.New(nil, name)
+	}
+	return example.Value, nil
+}
+
+func abortExampleNotFound(c *gin.Context, names []string, err error) {
+	c.JSON(http.StatusNotFound, gin.H{
+		"error":    err.Error(),
+		"examples": names,
+	})
+	c.Abort()
+}
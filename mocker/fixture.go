@@ -0,0 +1,235 @@
+package mocker
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/gin"
+)
+
+// errors
+var (
+	ErrorReadFixture1  = errutil.NewFactory("read fixture %q failed")
+	ErrorWriteFixture1 = errutil.NewFactory("write fixture %q failed")
+)
+
+// default directory of recorded fixtures
+const fixtureDefaultDir = "fixtures"
+
+// response headers not recorded into fixture, they are decided by server when replaying,
+// CORS headers are not recorded either, they are decided by CORS policy of mock server
+var fixtureSkipHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Date":              true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+}
+
+// fixture is a recorded request and response pair of proxy server
+type fixture struct {
+	Request  fixtureRequest  `json:"request"`
+	Response fixtureResponse `json:"response"`
+}
+
+type fixtureRequest struct {
+	Method   string `json:"method"`
+	Resource string `json:"resource"`
+	Path     string `json:"path"`
+	Query    string `json:"query,omitempty"`
+	Body     string `json:"body,omitempty"`
+}
+
+type fixtureResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+	Base64 bool        `json:"base64,omitempty"`
+}
+
+// fixtureDir return directory of fixtures in config
+func (t *Config) fixtureDir() string {
+	if t.FixturesDir == "" {
+		return fixtureDefaultDir
+	}
+	return t.FixturesDir
+}
+
+// readRequestBody return request body and restore it for later reading
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// normalizeQuery return query string sorted by name, parameters used to control mock server are removed
+func normalizeQuery(query url.Values) string {
+	result := url.Values{}
+	for name, values := range query {
+		switch name {
+		case queryMockStatus, queryMockExample, "pretty":
+			continue
+		}
+		result[name] = values
+	}
+	return result.Encode()
+}
+
+// normalizeBody return JSON and form body in canonical form, other bodies are kept as-is
+func normalizeBody(contentType string, body []byte) string {
+	switch {
+	case isJSONMIMEType(contentType):
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err == nil {
+			if normalized, err := json.Marshal(data); err == nil {
+				return string(normalized)
+			}
+		}
+	case mimetypeWithoutParams(contentType) == mimeTypeForm:
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return values.Encode()
+		}
+	}
+	return string(body)
+}
+
+// fixtureRequestOf return normalized request used as fixture key
+func (t *Mocker) fixtureRequestOf(req *http.Request, body []byte) fixtureRequest {
+	resource, _ := t.currentResourceMatcher().match(req.URL.Path)
+	if resource == "" {
+		resource = req.URL.Path
+	}
+	return fixtureRequest{
+		Method:   strings.ToUpper(req.Method),
+		Resource: resource,
+		Path:     req.URL.Path,
+		Query:    normalizeQuery(req.URL.Query()),
+		Body:     normalizeBody(req.Header.Get("Content-Type"), body),
+	}
+}
+
+// fixturePath return file path of fixture, keyed by method, requested path and normalized query and body,
+// fixtures are grouped in directory of RAML resource
+func fixturePath(dir string, request fixtureRequest) string {
+	hash := sha1.New()
+	for _, key := range []string{request.Method, request.Path, request.Query, request.Body} {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+	}
+
+	resourceDir := strings.Replace(strings.Trim(request.Resource, "/"), "/", "_", -1)
+	if resourceDir == "" {
+		resourceDir = "_"
+	}
+	name := request.Method + "-" + hex.EncodeToString(hash.Sum(nil))[:16] + ".json"
+	return filepath.Join(dir, resourceDir, name)
+}
+
+// recordFixture save proxied request and response into fixtures directory
+func (t *Mocker) recordFixture(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) error {
+	data := fixture{
+		Request: t.fixtureRequestOf(req, reqBody),
+		Response: fixtureResponse{
+			Status: resp.StatusCode,
+			Header: http.Header{},
+		},
+	}
+	for name, values := range resp.Header {
+		if fixtureSkipHeaders[http.CanonicalHeaderKey(name)] || isCORSHeader(name) {
+			continue
+		}
+		data.Response.Header[name] = values
+	}
+	if utf8.Valid(respBody) {
+		data.Response.Body = string(respBody)
+	} else {
+		data.Response.Body = base64.StdEncoding.EncodeToString(respBody)
+		data.Response.Base64 = true
+	}
+
+	path := fixturePath(t.config.fixtureDir(), data.Request)
+	raw, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		return ErrorWriteFixture1.New(err, path)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return ErrorWriteFixture1.New(err, path)
+	}
+	if err = ioutil.WriteFile(path, raw, 0644); err != nil {
+		return ErrorWriteFixture1.New(err, path)
+	}
+	logger.Debugf("Record fixture: %s %s -> %s", data.Request.Method, data.Request.Path, path)
+	return nil
+}
+
+// loadFixture return recorded fixture of request, return nil if not recorded
+func (t *Mocker) loadFixture(req *http.Request, body []byte) (*fixture, error) {
+	path := fixturePath(t.config.fixtureDir(), t.fixtureRequestOf(req, body))
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, ErrorReadFixture1.New(err, path)
+	}
+	result := &fixture{}
+	if err = json.Unmarshal(raw, result); err != nil {
+		return nil, ErrorReadFixture1.New(err, path)
+	}
+	return result, nil
+}
+
+// serveFixture serve recorded fixture of request, return false if not recorded
+func (t *Mocker) serveFixture(c *gin.Context) bool {
+	body, err := readRequestBody(c.Request)
+	if err != nil {
+		errutil.Trace(err)
+		return false
+	}
+	result, err := t.loadFixture(c.Request, body)
+	if err != nil {
+		errutil.Trace(err)
+		return false
+	}
+	if result == nil {
+		return false
+	}
+
+	data := []byte(result.Response.Body)
+	if result.Response.Base64 {
+		if data, err = base64.StdEncoding.DecodeString(result.Response.Body); err != nil {
+			errutil.Trace(err)
+			return false
+		}
+	}
+	outputHeader := c.Writer.Header()
+	for name, values := range result.Response.Header {
+		// CORS headers are already set by CORS policy of mock server
+		if isCORSHeader(name) {
+			continue
+		}
+		for _, value := range values {
+			outputHeader.Add(name, value)
+		}
+	}
+	c.Data(result.Response.Status, result.Response.Header.Get("Content-Type"), data)
+	c.Abort()
+	return true
+}
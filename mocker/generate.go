@@ -0,0 +1,345 @@
+package mocker
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+// generate limitations
+const (
+	generateMaxDepth     = 8
+	generateMinLength    = 5
+	generateMaxLength    = 10
+	generateMaxItems     = 3
+	generateMaxNumber    = 1000
+	generateNumberStep   = 0.01
+	generateDateTimeSpan = 3 * 365 * 24 * time.Hour
+)
+
+var generateDateTimeBase = time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// generator synthesizes random value satisfied RAML type declaration
+type generator struct {
+	rand  *rand.Rand
+	types parser.APITypes
+}
+
+// newGenerator return generator with random seed, output is reproducible if seed is not zero
+func newGenerator(seed int64, types parser.APITypes) *generator {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &generator{
+		rand:  rand.New(rand.NewSource(seed)),
+		types: types,
+	}
+}
+
+// generate return random value satisfied apiType
+func (g *generator) generate(apiType parser.APIType) interface{} {
+	return g.generateDepth(apiType, 0)
+}
+
+func (g *generator) generateDepth(apiType parser.APIType, depth int) interface{} {
+	if depth > generateMaxDepth {
+		return nil
+	}
+
+	if example, ok := typeExample(apiType); ok {
+		return example
+	}
+
+	if len(apiType.Enum.Array) > 0 {
+		return valueToInterface(*apiType.Enum.Array[g.rand.Intn(len(apiType.Enum.Array))])
+	}
+
+	expr := typeExpression(apiType)
+	members := splitUnionTypeExpression(expr)
+	switch {
+	case len(members) > 1:
+		member := parser.APIType{}
+		member.Type = members[g.rand.Intn(len(members))]
+		return g.generateDepth(member, depth+1)
+	case strings.HasSuffix(expr, "[]"):
+		item := parser.APIType{}
+		item.Type = trimTypeExpression(strings.TrimSuffix(expr, "[]"))
+		return g.generateArray(apiType, item, depth)
+	case expr == "":
+		if len(apiType.Properties.Slice()) > 0 {
+			return g.generateObject(apiType, depth)
+		}
+		if apiType.Items != nil {
+			return g.generateArray(apiType, *apiType.Items, depth)
+		}
+		return nil
+	}
+
+	switch expr {
+	case parser.TypeObject:
+		return g.generateObject(apiType, depth)
+	case parser.TypeArray:
+		item := parser.APIType{}
+		if apiType.Items != nil {
+			item = *apiType.Items
+		}
+		return g.generateArray(apiType, item, depth)
+	case parser.TypeString:
+		return g.generateString(apiType)
+	case parser.TypeInteger:
+		return g.generateInteger(apiType)
+	case parser.TypeNumber:
+		return g.generateNumber(apiType)
+	case parser.TypeBoolean:
+		return g.rand.Intn(2) == 1
+	case typeDateOnly:
+		return g.generateDateTime().Format("2006-01-02")
+	case typeTimeOnly:
+		return g.generateDateTime().Format("15:04:05")
+	case typeDateTimeOnly:
+		return g.generateDateTime().Format("2006-01-02T15:04:05")
+	case typeDateTime:
+		if strings.ToLower(apiType.Format) == "rfc2616" {
+			return g.generateDateTime().Format(http.TimeFormat)
+		}
+		return g.generateDateTime().Format(time.RFC3339)
+	case typeFile:
+		return g.generateString(apiType)
+	case typeAny, typeNil:
+		return nil
+	}
+
+	parent, exist := g.types[expr]
+	if !exist || parent == nil {
+		errutil.Trace(ErrorTypeNotFound1.New(nil, expr))
+		return nil
+	}
+	if isObjectType(g.types, apiType) {
+		return g.generateObject(apiType, depth)
+	}
+	return g.generateDepth(inheritAPIType(apiType, *parent), depth+1)
+}
+
+func (g *generator) generateObject(apiType parser.APIType, depth int) interface{} {
+	result := map[string]interface{}{}
+	for _, property := range objectProperties(g.types, apiType) {
+		result[property.Name] = g.generateDepth(property.APIType, depth+1)
+	}
+	return result
+}
+
+func (g *generator) generateArray(apiType parser.APIType, item parser.APIType, depth int) interface{} {
+	min := int(apiType.MinItems)
+	max := int(apiType.MaxItems)
+	if max <= 0 {
+		max = min + generateMaxItems
+	}
+	count := g.randomRange(min, max)
+
+	result := []interface{}{}
+	// only minItems items are generated at max depth, without going deeper
+	if depth >= generateMaxDepth {
+		for i := 0; i < min; i++ {
+			result = append(result, g.generateDepth(item, depth))
+		}
+		return result
+	}
+	for i := 0; i < count; i++ {
+		result = append(result, g.generateDepth(item, depth+1))
+	}
+	return result
+}
+
+func (g *generator) generateString(apiType parser.APIType) interface{} {
+	if apiType.Pattern != "" {
+		value, err := generatePattern(g.rand, apiType.Pattern)
+		if err == nil {
+			return value
+		}
+		errutil.Trace(err)
+	}
+
+	min := int(apiType.MinLength)
+	max := int(apiType.MaxLength)
+	if max <= 0 {
+		max = generateMaxLength
+		if max < min {
+			max = min
+		}
+	}
+	length := g.randomRange(min, max)
+	if length < generateMinLength && max >= generateMinLength {
+		length = generateMinLength
+	}
+
+	runes := make([]rune, length)
+	for i := range runes {
+		runes[i] = randomAlphanumeric(g.rand)
+	}
+	return string(runes)
+}
+
+// numberRange return minimum and maximum of apiType, use default if not declared
+func numberRange(apiType parser.APIType) (min float64, max float64) {
+	min, max = 0, generateMaxNumber
+	if apiType.Minimum != nil {
+		min = *apiType.Minimum
+		if apiType.Maximum == nil && max < min {
+			max = min + generateMaxNumber
+		}
+	}
+	if apiType.Maximum != nil {
+		max = *apiType.Maximum
+		if apiType.Minimum == nil && min > max {
+			min = max - generateMaxNumber
+		}
+	}
+	if max < min {
+		max = min
+	}
+	return
+}
+
+// largest float64 not exceeding max int64, float64(math.MaxInt64) is rounded up to 2^63
+var maxInt64Float = math.Nextafter(math.MaxInt64, 0)
+
+func (g *generator) generateInteger(apiType parser.APIType) interface{} {
+	min, max := numberRange(apiType)
+	min = math.Max(math.Ceil(min), math.MinInt64)
+	max = math.Min(math.Floor(max), maxInt64Float)
+	step := integerStep(apiType.MultipleOf)
+	value := g.randomMultiple(min, max, step) * step
+	switch {
+	case value > maxInt64Float:
+		return int64(math.MaxInt64)
+	case value < math.MinInt64:
+		return int64(math.MinInt64)
+	}
+	return int64(value)
+}
+
+// integerStep return the least positive integer which is multiple of multipleOf, 1 if multipleOf not declared,
+// e.g. 5 for 2.5 and 3 for 0.3
+func integerStep(multipleOf float64) float64 {
+	if multipleOf <= 0 {
+		return 1
+	}
+	for n := 1.0; n <= 1000; n++ {
+		step := n * multipleOf
+		rounded := math.Floor(step + 0.5)
+		if rounded >= 1 && math.Abs(step-rounded) <= 1e-9*step {
+			return rounded
+		}
+	}
+	return math.Ceil(multipleOf)
+}
+
+func (g *generator) generateNumber(apiType parser.APIType) interface{} {
+	min, max := numberRange(apiType)
+	step := apiType.MultipleOf
+	if step <= 0 {
+		step = generateNumberStep
+		if math.Ceil(snapInteger(min/step)) > math.Floor(snapInteger(max/step)) {
+			// no number of 2 decimals in range, e.g. minimum 0.001 and maximum 0.005
+			return min + g.rand.Float64()*(max-min)
+		}
+	}
+	return roundToStep(g.randomMultiple(min, max, step)*step, step)
+}
+
+// randomMultiple return random integer n that n*step is in [min, max],
+// the least n that n*step is not less than min is returned if there is no multiple in range
+func (g *generator) randomMultiple(min float64, max float64, step float64) float64 {
+	low := math.Ceil(snapInteger(min / step))
+	high := math.Floor(snapInteger(max / step))
+	if high <= low {
+		return low
+	}
+	span := high - low
+	if span < maxInt64Float {
+		return low + float64(g.rand.Int63n(int64(span)+1))
+	}
+	// span of extreme bounds overflows int64
+	return low + math.Floor(g.rand.Float64()*span)
+}
+
+// snapInteger return the nearest integer if value differs from it by float error of division,
+// e.g. 3 for 0.3/0.1 = 2.9999999999999996
+func snapInteger(value float64) float64 {
+	rounded := math.Floor(value + 0.5)
+	if math.Abs(value-rounded) <= 1e-9*math.Max(1, math.Abs(value)) {
+		return rounded
+	}
+	return value
+}
+
+// roundToStep round multiple of step to decimal places of step,
+// e.g. 0.3 for 3*0.1 = 0.30000000000000004
+func roundToStep(value float64, step float64) float64 {
+	decimals := 0
+	text := strconv.FormatFloat(step, 'f', -1, 64)
+	if index := strings.IndexByte(text, '.'); index >= 0 {
+		decimals = len(text) - index - 1
+	}
+	result, err := strconv.ParseFloat(strconv.FormatFloat(value, 'f', decimals, 64), 64)
+	if err != nil {
+		errutil.Trace(err)
+		return value
+	}
+	return result
+}
+
+func (g *generator) generateDateTime() time.Time {
+	return generateDateTimeBase.Add(time.Duration(g.rand.Int63n(int64(generateDateTimeSpan/time.Second))) * time.Second)
+}
+
+// randomRange return random integer in [min, max]
+func (g *generator) randomRange(min int, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + g.rand.Intn(max-min+1)
+}
+
+// typeExample return example declared in apiType, first named example in order of name
+func typeExample(apiType parser.APIType) (interface{}, bool) {
+	if !apiType.Examples.IsEmpty() {
+		names := []string{}
+		for name, example := range apiType.Examples {
+			if example != nil {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		if len(names) > 0 {
+			return valueToInterface(apiType.Examples[names[0]].Value), true
+		}
+	}
+	if apiType.Example.Value.Type != "" {
+		return valueToInterface(apiType.Example.Value), true
+	}
+	return nil, false
+}
+
+// valueToInterface convert parser value to generic JSON value
+func valueToInterface(value parser.Value) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		errutil.Trace(err)
+		return nil
+	}
+	var result interface{}
+	if err = json.Unmarshal(data, &result); err != nil {
+		errutil.Trace(err)
+		return nil
+	}
+	return result
+}
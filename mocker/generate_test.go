@@ -0,0 +1,136 @@
+package mocker
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_generateInteger(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	g := newGenerator(9527, parser.APITypes{})
+	floatPtr := func(value float64) *float64 {
+		return &value
+	}
+
+	// test fractional multipleOf
+	func() {
+		apiType := parser.APIType{}
+		apiType.Minimum = floatPtr(1)
+		apiType.Maximum = floatPtr(50)
+		apiType.MultipleOf = 2.5
+		for i := 0; i < 100; i++ {
+			value := g.generateInteger(apiType).(int64)
+			require.True(value >= 5 && value <= 50, "%d", value)
+			require.EqualValues(0, value%5, "%d", value)
+		}
+
+		apiType.MultipleOf = 0.3
+		for i := 0; i < 100; i++ {
+			value := g.generateInteger(apiType).(int64)
+			require.True(value >= 3 && value <= 48, "%d", value)
+			require.EqualValues(0, value%3, "%d", value)
+		}
+	}()
+
+	// test full int64 bounds
+	func() {
+		apiType := parser.APIType{}
+		apiType.Minimum = floatPtr(math.MinInt64)
+		apiType.Maximum = floatPtr(math.MaxInt64)
+		for i := 0; i < 100; i++ {
+			_, ok := g.generateInteger(apiType).(int64)
+			require.True(ok)
+		}
+
+		apiType.MultipleOf = 1024
+		for i := 0; i < 100; i++ {
+			value := g.generateInteger(apiType).(int64)
+			require.EqualValues(0, value%1024, "%d", value)
+		}
+	}()
+
+}
+
+func Test_generateNumber(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	g := newGenerator(9527, parser.APITypes{})
+	floatPtr := func(value float64) *float64 {
+		return &value
+	}
+
+	// test fractional multipleOf
+	func() {
+		tests := []struct {
+			minimum    float64
+			maximum    float64
+			multipleOf float64
+			decimals   int
+		}{
+			{0.1, 1, 0.25, 2},
+			{0.1, 1, 0.1, 1},
+			{0.3, 0.6, 0.1, 1},
+			{0.0015, 0.01, 0.001, 3},
+			{0.0001, 0.0019, 0.001, 3},
+		}
+		for _, test := range tests {
+			apiType := parser.APIType{}
+			apiType.Minimum = floatPtr(test.minimum)
+			apiType.Maximum = floatPtr(test.maximum)
+			apiType.MultipleOf = test.multipleOf
+			for i := 0; i < 100; i++ {
+				value := g.generateNumber(apiType).(float64)
+				require.True(value >= test.minimum && value <= test.maximum, "%v %v", test, value)
+				quotient := value / test.multipleOf
+				require.InDelta(math.Floor(quotient+0.5), quotient, 1e-9, "%v %v", test, value)
+				text := strconv.FormatFloat(value, 'f', -1, 64)
+				if index := strings.IndexByte(text, '.'); index >= 0 {
+					require.True(len(text)-index-1 <= test.decimals, "%v %v", test, value)
+				}
+			}
+		}
+	}()
+
+	// test range without number of 2 decimals
+	func() {
+		apiType := parser.APIType{}
+		apiType.Minimum = floatPtr(0.001)
+		apiType.Maximum = floatPtr(0.005)
+		for i := 0; i < 100; i++ {
+			value := g.generateNumber(apiType).(float64)
+			require.True(value >= 0.001 && value <= 0.005, "%v", value)
+		}
+	}()
+}
+
+func Test_generateArray(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	g := newGenerator(9527, parser.APITypes{})
+
+	// test minItems at max depth
+	func() {
+		apiType := parser.APIType{}
+		apiType.MinItems = 2
+		item := parser.APIType{}
+		item.Type = parser.TypeString
+
+		value := g.generateArray(apiType, item, generateMaxDepth).([]interface{})
+		require.Len(value, 2)
+		for _, element := range value {
+			require.IsType("", element)
+		}
+
+		apiType.MinItems = 0
+		require.Empty(g.generateArray(apiType, item, generateMaxDepth))
+	}()
+}
@@ -0,0 +1,291 @@
+package mocker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/gin"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+// errors
+var (
+	ErrorBuildRoutesFailed1 = errutil.NewFactory("build routes failed: %v")
+	ErrorNoRAMLDocument     = errutil.NewFactory("no RAML document loaded")
+)
+
+// response header of last reload error, sent on every response until reload succeeded
+const headerMockReloadError = "X-Mock-Reload-Error"
+
+// context key of mocker config, used by helpers without mocker
+const contextKeyConfig = "mocker.config"
+
+// ReloadStatus is result of loading RAML file
+type ReloadStatus struct {
+	RAMLFile string     `json:"ramlFile"`
+	LoadedAt time.Time  `json:"loadedAt"`
+	Error    string     `json:"error,omitempty"`
+	FailedAt *time.Time `json:"failedAt,omitempty"`
+}
+
+// Mocker is a mock server of RAML document, serves requests as http.Handler,
+// config and runtime state of routes, stateful records, request journal and contract report is kept per mocker,
+// so mockers of different RAML files can run in one process
+type Mocker struct {
+	config      *Config
+	adminEngine *gin.Engine
+	reloadMutex sync.Mutex
+	binding     atomic.Value
+	statusMutex sync.RWMutex
+	status      ReloadStatus
+
+	admin    *adminState
+	journal  *journal
+	contract *contractReport
+
+	transportMutex sync.Mutex
+	transports     map[string]*http.Transport
+
+	closeMutex  sync.Mutex
+	watcher     *fileWatcher
+	testServers []*httptest.Server
+}
+
+// New return mocker of RAML file in config
+func New(conf Config) (*Mocker, error) {
+	rootdoc, err := parseRAMLFile(&conf)
+	if err != nil {
+		return nil, err
+	}
+	return newMocker(&conf, rootdoc)
+}
+
+// NewFromRootDocument return mocker of parsed RAML document, RAML file in config is used for reload
+func NewFromRootDocument(conf Config, rootdoc parser.RootDocument) (*Mocker, error) {
+	return newMocker(&conf, rootdoc)
+}
+
+// newMocker return mocker with routes of RAML document bound, return error if routes can not be built
+func newMocker(conf *Config, rootdoc parser.RootDocument) (*Mocker, error) {
+	mocker := &Mocker{
+		config:   conf,
+		admin:    newAdminState(conf.Overrides),
+		journal:  newJournal(int(conf.JournalSize)),
+		contract: newContractReport(),
+
+		transports: map[string]*http.Transport{},
+	}
+	mocker.binding.Store(&binding{
+		matcher: newResourceMatcher(parser.RootDocument{}),
+		routes:  adminRoutes{},
+		store:   newStateStore(),
+	})
+	mocker.adminEngine = mocker.buildAdmin()
+	if err := mocker.bind(rootdoc); err != nil {
+		return nil, err
+	}
+	mocker.setReloadResult(nil)
+	return mocker, nil
+}
+
+func (t *Mocker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if prefix := t.config.pathPrefix(); prefix != "" {
+		if !hasPathPrefix(req.URL.Path, prefix) {
+			http.NotFound(w, req)
+			return
+		}
+		req = stripPathPrefix(req, prefix)
+	}
+	// administrative API is served by its own engine, not conflicted with RAML resources like /{id}
+	if hasPathPrefix(req.URL.Path, adminPrefix) {
+		t.adminEngine.ServeHTTP(w, req)
+		return
+	}
+	engine := t.currentBinding().engine
+	if engine == nil {
+		http.Error(w, ErrorNoRAMLDocument.New(nil).Error(), http.StatusServiceUnavailable)
+		return
+	}
+	engine.ServeHTTP(w, req)
+}
+
+// PathPrefix return path prefix which mocker is mounted on, empty if mounted on root
+func (t *Mocker) PathPrefix() string {
+	return t.config.pathPrefix()
+}
+
+// NewTestServer start httptest server of mocker, closed by Close of mocker
+func (t *Mocker) NewTestServer() *httptest.Server {
+	server := httptest.NewServer(t)
+	t.closeMutex.Lock()
+	defer t.closeMutex.Unlock()
+	t.testServers = append(t.testServers, server)
+	return server
+}
+
+// Close stop watching RAML file, close test servers and idle proxy connections of mocker
+func (t *Mocker) Close() (err error) {
+	t.closeMutex.Lock()
+	defer t.closeMutex.Unlock()
+	for _, server := range t.testServers {
+		server.Close()
+	}
+	t.testServers = nil
+	t.closeProxyTransports()
+	if t.watcher != nil {
+		err = t.watcher.Close()
+		t.watcher = nil
+	}
+	return
+}
+
+// watch reload mocker after RAML file in config or its dependencies changed, until mocker closed
+func (t *Mocker) watch() (err error) {
+	watcher, err := watch(t.config.RAMLFile, t.reload)
+	if err != nil {
+		return
+	}
+	t.closeMutex.Lock()
+	defer t.closeMutex.Unlock()
+	t.watcher = watcher
+	return nil
+}
+
+// binding is runtime state built from one RAML document,
+// published as a whole so requests never see parts of two documents
+type binding struct {
+	engine  *gin.Engine
+	matcher *resourceMatcher
+	routes  adminRoutes
+	store   *stateStore
+}
+
+// currentBinding return state of RAML document currently bound, engine is nil if no document bound yet
+func (t *Mocker) currentBinding() *binding {
+	return t.binding.Load().(*binding)
+}
+
+// bind build engine and state of RAML document fully, then swap them in at once,
+// stateful store is carried over, so records are kept across reloads,
+// called before mocker served or with reloadMutex held
+func (t *Mocker) bind(rootdoc parser.RootDocument) (err error) {
+	engine, routes, err := t.build(rootdoc)
+	if err != nil {
+		return
+	}
+
+	result := &binding{
+		engine:  engine,
+		matcher: newResourceMatcher(rootdoc),
+		routes:  newAdminRoutes(routes),
+		store:   t.currentBinding().store,
+	}
+	if t.config.Stateful {
+		result.store.seed(rootdoc, stateResources(rootdoc))
+	}
+	t.binding.Store(result)
+	return nil
+}
+
+// build return engine and bound routes of RAML document, without changing state of running server
+func (t *Mocker) build(rootdoc parser.RootDocument) (engine *gin.Engine, routes []adminRoute, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = ErrorBuildRoutesFailed1.New(nil, recovered)
+		}
+	}()
+
+	if _, err := json.Marshal(rootdoc); err != nil {
+		errutil.Trace(err)
+	}
+
+	engine = gin.Default()
+	engine.Use(gin.ErrorLogger())
+	engine.Use(t.configMiddleware)
+	engine.Use(t.reloadErrorMiddleware)
+	engine.Use(t.journalMiddleware)
+	engine.Use(t.corsMiddleware)
+	routes = t.bindRootDocument(engine, rootdoc)
+	engine.NoRoute(t.proxyRoute)
+	engine.NoMethod(t.proxyRoute)
+	return engine, routes, nil
+}
+
+// buildAdmin return engine of administrative API, kept through reloads of RAML document
+func (t *Mocker) buildAdmin() *gin.Engine {
+	engine := gin.Default()
+	engine.Use(gin.ErrorLogger())
+	engine.Use(t.configMiddleware)
+	engine.Use(t.reloadErrorMiddleware)
+	engine.Use(t.corsMiddleware)
+	t.bindAdminRoutes(engine)
+	return engine
+}
+
+// reload parse and validate RAML file in config, then swap in engine of it,
+// keep serving previous document if any step failed, concurrent reloads are done one by one
+func (t *Mocker) reload() (err error) {
+	t.reloadMutex.Lock()
+	defer t.reloadMutex.Unlock()
+	defer func() {
+		t.setReloadResult(err)
+	}()
+
+	rootdoc, err := parseRAMLFile(t.config)
+	if err != nil {
+		return
+	}
+	return t.bind(rootdoc)
+}
+
+func (t *Mocker) setReloadResult(err error) {
+	t.statusMutex.Lock()
+	defer t.statusMutex.Unlock()
+	t.status.RAMLFile = t.config.RAMLFile
+	if err != nil {
+		logger.Errorf("load RAML file %q failed, keep serving previous document: %v", t.config.RAMLFile, err)
+		now := time.Now()
+		t.status.Error = err.Error()
+		t.status.FailedAt = &now
+		return
+	}
+	t.status.LoadedAt = time.Now()
+	t.status.Error = ""
+	t.status.FailedAt = nil
+}
+
+// reloadStatus return result of last loading RAML file
+func (t *Mocker) reloadStatus() ReloadStatus {
+	t.statusMutex.RLock()
+	defer t.statusMutex.RUnlock()
+	return t.status
+}
+
+// reloadErrorMiddleware set header of last reload error on responses
+func (t *Mocker) reloadErrorMiddleware(c *gin.Context) {
+	if status := t.reloadStatus(); status.Error != "" {
+		// header value must be single line
+		c.Header(headerMockReloadError, strings.Join(strings.Fields(status.Error), " "))
+	}
+}
+
+// configMiddleware set config of mocker into request context
+func (t *Mocker) configMiddleware(c *gin.Context) {
+	c.Set(contextKeyConfig, t.config)
+}
+
+// contextConfig return config of mocker serving request, empty config if not set
+func contextConfig(c *gin.Context) *Config {
+	if conf, exist := c.Get(contextKeyConfig); exist {
+		if result, ok := conf.(*Config); ok {
+			return result
+		}
+	}
+	return &Config{}
+}
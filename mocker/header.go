@@ -0,0 +1,60 @@
+package mocker
+
+import (
+	"net/http"
+
+	"github.com/tsaikd/gin"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+// response headers decided by mock server, not overridden by RAML declaration
+var reservedResponseHeaders = map[string]bool{
+	"Content-Type":      true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+}
+
+// writeResponseHeaders set headers declared in RAML response with example value,
+// required header without example is generated from its type, optional one is omitted
+func writeResponseHeaders(c *gin.Context, response *parser.Response, types parser.APITypes, seed int64) {
+	if response == nil {
+		return
+	}
+
+	gen := newGenerator(seed, types)
+	output := c.Writer.Header()
+	for _, header := range response.Headers.Slice() {
+		name := http.CanonicalHeaderKey(header.Name)
+		if reservedResponseHeaders[name] || isCORSHeader(name) {
+			continue
+		}
+
+		value, ok := typeExample(header.APIType)
+		if !ok {
+			if !header.Required {
+				continue
+			}
+			if value = gen.generate(header.APIType); value == nil {
+				value = gen.generateString(header.APIType)
+			}
+		}
+
+		output.Del(name)
+		for _, text := range headerValues(value) {
+			output.Add(name, text)
+		}
+	}
+}
+
+// headerValues return header values of generic JSON value, array is written as repeated header
+func headerValues(value interface{}) []string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return []string{formatText(value)}
+	}
+	values := []string{}
+	for _, item := range items {
+		values = append(values, formatText(item))
+	}
+	return values
+}
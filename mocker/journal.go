@@ -0,0 +1,159 @@
+package mocker
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tsaikd/gin"
+)
+
+// journal limitations
+const (
+	journalDefaultSize = 1000
+	journalMaxBodySize = 64 * 1024
+)
+
+// context key of matched RAML resource, set by route handler
+const contextKeyResource = "mocker.resource"
+
+// JournalEntry is a request recorded in journal
+type JournalEntry struct {
+	Time     time.Time   `json:"time"`
+	Method   string      `json:"method"`
+	Path     string      `json:"path"`
+	Query    string      `json:"query,omitempty"`
+	Resource string      `json:"resource,omitempty"`
+	Headers  http.Header `json:"headers"`
+	Body     string      `json:"body,omitempty"`
+	Valid    bool        `json:"valid"`
+	Errors   []string    `json:"errors,omitempty"`
+	Status   int         `json:"status"`
+}
+
+// JournalFilter select journal entries, empty field matches all
+type JournalFilter struct {
+	Resource string
+	Method   string
+}
+
+func (t JournalFilter) match(entry JournalEntry) bool {
+	if t.Resource != "" && toRAMLResource(t.Resource) != entry.Resource {
+		return false
+	}
+	if t.Method != "" && !strings.EqualFold(t.Method, entry.Method) {
+		return false
+	}
+	return true
+}
+
+// journal is a bounded list of recorded requests, the oldest entry is dropped when full
+type journal struct {
+	mutex   sync.RWMutex
+	size    int
+	entries []JournalEntry
+}
+
+func newJournal(size int) *journal {
+	if size <= 0 {
+		size = journalDefaultSize
+	}
+	return &journal{
+		size:    size,
+		entries: []JournalEntry{},
+	}
+}
+
+func (t *journal) add(entry JournalEntry) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.entries) >= t.size {
+		t.entries = append(t.entries[:0], t.entries[len(t.entries)-t.size+1:]...)
+	}
+	t.entries = append(t.entries, entry)
+}
+
+func (t *journal) list(filter JournalFilter) []JournalEntry {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	entries := []JournalEntry{}
+	for _, entry := range t.entries {
+		if filter.match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (t *journal) clear() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.entries = []JournalEntry{}
+}
+
+// Journal return recorded requests matched filter in order of arrival
+func (t *Mocker) Journal(filter JournalFilter) []JournalEntry {
+	return t.journal.list(filter)
+}
+
+// ClearJournal remove all recorded requests
+func (t *Mocker) ClearJournal() {
+	t.journal.clear()
+}
+
+// journalMiddleware record requests into journal, administrative API requests are not recorded
+func (t *Mocker) journalMiddleware(c *gin.Context) {
+	entry := JournalEntry{
+		Time:    time.Now(),
+		Method:  c.Request.Method,
+		Path:    c.Request.URL.Path,
+		Query:   c.Request.URL.RawQuery,
+		Headers: cloneHeader(c.Request.Header),
+	}
+	if c.Request.Body != nil {
+		// only head of body is read for journal, the rest is still streamed to handler or proxy server
+		body, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, journalMaxBodySize))
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		c.Request.Body = readCloser{
+			Reader: io.MultiReader(bytes.NewReader(body), c.Request.Body),
+			Closer: c.Request.Body,
+		}
+		entry.Body = string(body)
+	}
+
+	c.Next()
+
+	if resource, exist := c.Get(contextKeyResource); exist {
+		entry.Resource, _ = resource.(string)
+	}
+	entry.Errors = c.Errors.Errors()
+	if violations, exist := c.Get(contextKeyViolations); exist {
+		for _, item := range violations.([]violation) {
+			entry.Errors = append(entry.Errors, item.Error())
+		}
+	}
+	entry.Valid = len(entry.Errors) < 1
+	entry.Status = c.Writer.Status()
+	t.journal.add(entry)
+}
+
+// readCloser read from Reader and close by Closer, e.g. request body partially read ahead
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func cloneHeader(header http.Header) http.Header {
+	result := http.Header{}
+	for name, values := range header {
+		result[name] = append([]string{}, values...)
+	}
+	return result
+}
@@ -0,0 +1,192 @@
+package mocker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MockServer_Admin(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", mimeTypeJSON)
+		w.Write([]byte(`{"name":"upstream"}`))
+	}))
+	defer upstream.Close()
+
+	conf := &Config{
+		RAMLFile: "../example/multiple-responses.raml",
+	}
+
+	rootdoc, err := parseRAMLFile(conf)
+	require.NoError(err)
+
+	mock, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(mock)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	adminRequest := func(method string, path string, body string) *http.Response {
+		req, err := http.NewRequest(method, ts.URL+adminPrefix+path, bytes.NewBufferString(body))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeJSON)
+		res, err := client.Do(req)
+		require.NoError(err)
+		return res
+	}
+
+	// test list routes
+	func() {
+		res := adminRequest("GET", "/routes", "")
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		routes := []adminRoute{}
+		err := json.NewDecoder(res.Body).Decode(&routes)
+		require.NoError(err)
+		require.NoError(res.Body.Close())
+		require.Len(routes, 2)
+		require.Equal("DELETE", routes[0].Method)
+		require.Equal("/user", routes[0].Resource)
+		require.Equal("GET", routes[1].Method)
+	}()
+
+	// test override status code
+	func() {
+		res := adminRequest("PUT", "/overrides", `{"method":"get","resource":"/user","status":404,"delay":"50ms"}`)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		start := time.Now()
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusNotFound, res.StatusCode)
+		require.True(time.Since(start) >= 50*time.Millisecond)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Contains(body.Map, "message")
+
+		// override is not recorded as request header of client
+		entries := mock.Journal(JournalFilter{Method: "GET", Resource: "/user"})
+		require.NotEmpty(entries)
+		require.Empty(entries[len(entries)-1].Headers.Get(headerMockStatus))
+	}()
+
+	// test override delay stop waiting when client cancel request
+	func() {
+		res := adminRequest("PUT", "/overrides", `{"method":"GET","resource":"/user","delay":"5s"}`)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		mock.ClearJournal()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequest("GET", ts.URL+"/user", nil)
+		require.NoError(err)
+		_, err = client.Do(req.WithContext(ctx))
+		require.Error(err)
+
+		start := time.Now()
+		for len(mock.Journal(JournalFilter{})) < 1 && time.Since(start) < time.Second {
+			time.Sleep(10 * time.Millisecond)
+		}
+		require.Len(mock.Journal(JournalFilter{}), 1)
+
+		res = adminRequest("PUT", "/overrides", `{"method":"GET","resource":"/user","status":404}`)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+	}()
+
+	// test status code requested by client take precedence
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/user", nil)
+		require.NoError(err)
+		req.Header.Set(headerMockStatus, "200")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+	}()
+
+	// test override not existed route
+	func() {
+		res := adminRequest("PUT", "/overrides", `{"method":"POST","resource":"/user","status":500}`)
+		require.EqualValues(http.StatusNotFound, res.StatusCode)
+	}()
+
+	// test override invalid delay
+	func() {
+		res := adminRequest("PUT", "/overrides", `{"method":"GET","resource":"/user","delay":"soon"}`)
+		require.EqualValues(http.StatusBadRequest, res.StatusCode)
+	}()
+
+	// test override invalid status code
+	func() {
+		res := adminRequest("PUT", "/overrides", `{"method":"GET","resource":"/user","status":42}`)
+		require.EqualValues(http.StatusBadRequest, res.StatusCode)
+	}()
+
+	// test clear overrides
+	func() {
+		res := adminRequest("DELETE", "/overrides", "")
+		require.EqualValues(http.StatusNoContent, res.StatusCode)
+
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+	}()
+
+	// test toggle proxy without proxy server
+	func() {
+		res := adminRequest("PUT", "/proxy", `{"resource":"/user","enabled":true}`)
+		require.EqualValues(http.StatusBadRequest, res.StatusCode)
+	}()
+
+	// test toggle proxy
+	func() {
+		conf.Proxy = upstream.URL
+
+		res := adminRequest("PUT", "/proxy", `{"resource":"/user","enabled":true}`)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("upstream", body.Map["name"].String)
+	}()
+
+	// test force reload keep proxy toggles
+	func() {
+		res := adminRequest("POST", "/reload", "")
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("upstream", body.Map["name"].String)
+	}()
+
+	// test reset state
+	func() {
+		res := adminRequest("DELETE", "/state", "")
+		require.EqualValues(http.StatusNoContent, res.StatusCode)
+
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("Bob", body.Map["name"].String)
+	}()
+}
@@ -0,0 +1,151 @@
+package mocker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoadConfigFile(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	fileConfig, err := LoadConfigFile("../example/mocker-config.yaml")
+	require.NoError(err)
+
+	conf := Config{
+		RAMLFile: "api.raml",
+		Port:     5000,
+		Seed:     7,
+	}
+	err = fileConfig.Apply(&conf, func(key string) bool {
+		// port set by command line flag
+		return key == "port"
+	})
+	require.NoError(err)
+	require.Equal("../example/multiple-responses.raml", conf.RAMLFile)
+	require.EqualValues(5000, conf.Port)
+	require.EqualValues(42, conf.Seed)
+	require.Equal(filepath.Join("..", "example", "fixtures"), conf.FixturesDir)
+	require.Equal("http://origin.backend:8080", conf.Proxy)
+	require.Len(conf.ProxyTargets, 1)
+	require.Equal("/users", conf.ProxyTargets[0].PathPrefix)
+	require.True(conf.Resources["/user"])
+	require.Equal(10*time.Millisecond, conf.Delay)
+	require.Equal("secret", conf.AdminToken)
+	require.Len(conf.Overrides, 1)
+
+	require.NoError(ValidateConfig(conf))
+
+	conf.Overrides = []RouteOverride{{Method: "POST", Resource: "/user"}}
+	err = ValidateConfig(conf)
+	require.Error(err)
+	require.True(ErrorConfigRouteNotFound2.Match(err))
+
+	require.Error(FileConfig{Delay: &[]string{"soon"}[0]}.Validate())
+	require.Equal("", FindConfigFile("../example/multiple-responses.raml"))
+}
+
+func Test_LoadConfigFileAPIs(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	fileConfig, err := LoadConfigFile("../example/mocker-apis.yaml")
+	require.NoError(err)
+
+	confs, err := fileConfig.Configs(Config{RAMLFile: "api.raml", Port: 5000}, func(key string) bool {
+		// port set by command line flag
+		return key == "port"
+	})
+	require.NoError(err)
+	require.Len(confs, 2)
+
+	require.Equal("../example/stateful-api.raml", confs[0].RAMLFile)
+	require.Equal("/billing", confs[0].PathPrefix)
+	require.True(confs[0].Stateful)
+	require.EqualValues(42, confs[0].Seed)
+
+	require.Equal("../example/multiple-responses.raml", confs[1].RAMLFile)
+	require.Equal("/users", confs[1].PathPrefix)
+	require.False(confs[1].Stateful)
+	require.EqualValues(7, confs[1].Seed)
+
+	// port of API in config file is overridden by command line flag too
+	for _, conf := range confs {
+		require.EqualValues(5000, conf.Port)
+		require.NoError(ValidateConfig(conf))
+	}
+
+	confs, err = fileConfig.Configs(Config{RAMLFile: "api.raml", Port: 5000}, func(string) bool { return false })
+	require.NoError(err)
+	require.Len(confs, 2)
+	require.EqualValues(4100, confs[0].Port)
+	require.EqualValues(4200, confs[1].Port)
+
+	confs, err = FileConfig{}.Configs(Config{RAMLFile: "api.raml"}, func(string) bool { return false })
+	require.NoError(err)
+	require.Len(confs, 1)
+	require.Equal("api.raml", confs[0].RAMLFile)
+}
+
+func Test_MockServer_ConfigFile(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	fileConfig, err := LoadConfigFile("../example/mocker-config.yaml")
+	require.NoError(err)
+
+	conf := &Config{}
+	require.NoError(fileConfig.Apply(conf, func(string) bool { return false }))
+	conf.Proxy = ""
+	conf.ProxyTargets = nil
+
+	rootdoc, err := parseRAMLFile(conf)
+	require.NoError(err)
+
+	handler, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	// test route override and delay in config
+	func() {
+		start := time.Now()
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusNotFound, res.StatusCode)
+		require.True(time.Since(start) >= 10*time.Millisecond)
+		require.NoError(res.Body.Close())
+	}()
+
+	// test admin token
+	func() {
+		res, err := client.Get(ts.URL + adminPrefix + "/routes")
+		require.NoError(err)
+		require.EqualValues(http.StatusUnauthorized, res.StatusCode)
+		require.NoError(res.Body.Close())
+
+		req, err := http.NewRequest("GET", ts.URL+adminPrefix+"/routes", nil)
+		require.NoError(err)
+		req.Header.Set("Authorization", "Bearer secret")
+		res, err = client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.NoError(res.Body.Close())
+
+		req, err = http.NewRequest("GET", ts.URL+adminPrefix+"/routes", nil)
+		require.NoError(err)
+		req.Header.Set("X-Mock-Admin-Token", "secret")
+		res, err = client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.NoError(res.Body.Close())
+	}()
+}
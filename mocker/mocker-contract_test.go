@@ -0,0 +1,116 @@
+package mocker
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_Contract(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			w.Header().Set("Content-Type", mimeTypeJSON)
+			// accepted encoding of client is forwarded by proxy
+			if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+				w.Header().Set("Content-Encoding", "gzip")
+				writer := gzip.NewWriter(w)
+				writer.Write([]byte(`{"name":9527}`))
+				writer.Close()
+				return
+			}
+			w.Write([]byte(`{"name":9527}`))
+		case "DELETE":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer upstream.Close()
+
+	conf := &Config{
+		Proxy:    upstream.URL,
+		Contract: true,
+	}
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/multiple-responses.raml")
+	require.NoError(err)
+
+	mock, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(mock)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+	mock.admin.setProxy("/user", true)
+
+	// test traffic not altered
+	func() {
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.True(res.Uncompressed)
+
+		body := getBodyValueForJSONType(t, res)
+		require.EqualValues(9527, body.Map["name"].Integer)
+
+		req, err := http.NewRequest("DELETE", ts.URL+"/user", nil)
+		require.NoError(err)
+		res, err = client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusInternalServerError, res.StatusCode)
+		require.NoError(res.Body.Close())
+
+		res, err = client.Get(ts.URL + "/undeclared")
+		require.NoError(err)
+		require.NoError(res.Body.Close())
+	}()
+
+	// test contract report
+	func() {
+		res, err := client.Get(ts.URL + adminPrefix + "/contract")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		entries := []contractEntry{}
+		err = json.NewDecoder(res.Body).Decode(&entries)
+		require.NoError(err)
+		require.NoError(res.Body.Close())
+		require.Len(entries, 2)
+
+		require.Equal("GET", entries[0].Method)
+		require.Equal("/user", entries[0].Resource)
+		// compressed response body is decoded before checking
+		require.Len(entries[0].Violations, 1)
+		require.Equal(locationResponseBody, entries[0].Violations[0].Location)
+		require.Equal("/name", entries[0].Violations[0].Pointer)
+
+		require.Equal("DELETE", entries[1].Method)
+		require.Len(entries[1].Violations, 1)
+		require.Equal(locationStatus, entries[1].Violations[0].Location)
+	}()
+
+	// test clear contract report
+	func() {
+		req, err := http.NewRequest("DELETE", ts.URL+adminPrefix+"/contract", nil)
+		require.NoError(err)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusNoContent, res.StatusCode)
+		require.Empty(mock.contract.list())
+	}()
+}
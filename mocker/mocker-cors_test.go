@@ -0,0 +1,100 @@
+package mocker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_CORS(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	conf := &Config{
+		CORSOrigins:     []string{"https://app.example.com"},
+		CORSMethods:     []string{"GET", "POST"},
+		CORSCredentials: true,
+		CORSMaxAge:      600,
+	}
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/response-headers.raml")
+	require.NoError(err)
+
+	handler, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	// test preflight without proxy
+	func() {
+		req, err := http.NewRequest("OPTIONS", ts.URL+"/items", nil)
+		require.NoError(err)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusNoContent, res.StatusCode)
+		require.Equal("https://app.example.com", res.Header.Get("Access-Control-Allow-Origin"))
+		require.Equal("true", res.Header.Get("Access-Control-Allow-Credentials"))
+		require.Equal("GET, POST", res.Header.Get("Access-Control-Allow-Methods"))
+		require.Equal("Content-Type", res.Header.Get("Access-Control-Allow-Headers"))
+		require.Equal("600", res.Header.Get("Access-Control-Max-Age"))
+	}()
+
+	// test preflight of disallowed origin
+	func() {
+		req, err := http.NewRequest("OPTIONS", ts.URL+"/items", nil)
+		require.NoError(err)
+		req.Header.Set("Origin", "https://evil.example.com")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusForbidden, res.StatusCode)
+		require.Empty(res.Header.Get("Access-Control-Allow-Origin"))
+	}()
+
+	// test RAML response headers exposed
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/items", nil)
+		require.NoError(err)
+		req.Header.Set("Origin", "https://app.example.com")
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.Equal("https://app.example.com", res.Header.Get("Access-Control-Allow-Origin"))
+		require.Equal("Etag, Link, X-Request-Id, X-Total-Count", res.Header.Get("Access-Control-Expose-Headers"))
+		require.Contains(res.Header["Vary"], "Origin")
+	}()
+
+	// test same-origin request without CORS headers
+	func() {
+		res, err := client.Get(ts.URL + "/items")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.Empty(res.Header.Get("Access-Control-Allow-Origin"))
+	}()
+}
+
+func Test_CORSAllowOrigin(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	conf := &Config{}
+	require.Equal("*", conf.corsAllowOrigin("https://app.example.com"))
+
+	conf = &Config{CORSOrigins: []string{"*"}, CORSCredentials: true}
+	require.Equal("https://app.example.com", conf.corsAllowOrigin("https://app.example.com"))
+
+	conf = &Config{CORSOrigins: []string{"https://app.example.com"}}
+	require.Equal("", conf.corsAllowOrigin("https://other.example.com"))
+}
@@ -0,0 +1,64 @@
+package mocker
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MockServer_ExampleOrder(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	order := loadExampleOrder("../example/example-order.raml")
+	require.Equal([]string{"zoe", "adam", "mike"}, order[exampleKey("get", "/user", http.StatusOK, mimeTypeJSON)])
+	require.Equal([]string{"second", "first"}, order[exampleKey("GET", "/user/:id", http.StatusOK, mimeTypeJSON)])
+	require.Equal([]string{"second", "first"}, order[exampleKey("GET", "/group", http.StatusOK, mimeTypeJSON)])
+	require.Empty(loadExampleOrder("../example"))
+
+	mock, err := New(Config{RAMLFile: "../example/example-order.raml"})
+	require.NoError(err)
+	defer mock.Close()
+
+	ts := mock.NewTestServer()
+	client := http.DefaultClient
+
+	// test the first declared example used by default
+	func() {
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("Zoe", body.Map["name"].String)
+
+		res, err = client.Get(ts.URL + "/user/1")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		body = getBodyValueForJSONType(t, res)
+		require.Equal("Second", body.Map["name"].String)
+
+		// examples included from file
+		res, err = client.Get(ts.URL + "/group")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		body = getBodyValueForJSONType(t, res)
+		require.Equal("Second", body.Map["name"].String)
+	}()
+
+	// test example names listed in declaration order
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/user", nil)
+		require.NoError(err)
+		req.Header.Set(headerMockExample, "unknown")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusNotFound, res.StatusCode)
+		body := getBodyValueForJSONType(t, res)
+		require.Len(body.Map["examples"].Array, 3)
+		require.Equal("zoe", body.Map["examples"].Array[0].String)
+		require.Equal("adam", body.Map["examples"].Array[1].String)
+		require.Equal("mike", body.Map["examples"].Array[2].String)
+	}()
+}
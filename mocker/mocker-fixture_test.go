@@ -0,0 +1,180 @@
+package mocker
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_Fixture(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", mimeTypeJSON)
+		w.Header().Set("X-Upstream", "true")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Write([]byte(`{"name":"upstream","path":"` + r.URL.Path + `","query":"` + r.URL.RawQuery + `"}`))
+	}))
+	defer upstream.Close()
+
+	dir, err := ioutil.TempDir("", "go-raml-mocker-fixtures")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	conf := &Config{
+		Proxy:       upstream.URL,
+		FixturesDir: dir,
+		Record:      true,
+	}
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/multiple-responses.raml")
+	require.NoError(err)
+
+	mock, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(mock)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	// test record proxied requests
+	func() {
+		res, err := client.Get(ts.URL + "/remote?b=2&a=1")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.NoError(res.Body.Close())
+
+		mock.admin.setProxy("/user", true)
+		defer mock.admin.setProxy("/user", false)
+
+		res, err = client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.NoError(res.Body.Close())
+
+		files, err := filepath.Glob(filepath.Join(dir, "*", "GET-*.json"))
+		require.NoError(err)
+		require.Len(files, 2)
+		for _, file := range files {
+			raw, err := ioutil.ReadFile(file)
+			require.NoError(err)
+			require.NotContains(string(raw), "Access-Control-Allow-Origin")
+		}
+	}()
+
+	conf.Proxy = ""
+	conf.Record = false
+
+	// test RAML example served without replay
+	func() {
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("Bob", body.Map["name"].String)
+	}()
+
+	conf.Replay = true
+
+	// test replay fixture before RAML example
+	func() {
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.Equal("true", res.Header.Get("X-Upstream"))
+
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("upstream", body.Map["name"].String)
+	}()
+
+	// test replay fixture with normalized query without proxy server
+	func() {
+		res, err := client.Get(ts.URL + "/remote?a=1&b=2")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("upstream", body.Map["name"].String)
+		require.Equal("b=2&a=1", body.Map["query"].String)
+	}()
+
+	// test not recorded request
+	func() {
+		res, err := client.Get(ts.URL + "/remote?a=2")
+		require.NoError(err)
+		require.EqualValues(http.StatusNotFound, res.StatusCode)
+	}()
+
+	// test replay with CORS headers of mock server only
+	func() {
+		conf.CORSOrigins = []string{"https://app.example.com"}
+		defer func() {
+			conf.CORSOrigins = nil
+		}()
+
+		req, err := http.NewRequest("GET", ts.URL+"/user", nil)
+		require.NoError(err)
+		req.Header.Set("Origin", "https://app.example.com")
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.Equal("true", res.Header.Get("X-Upstream"))
+		require.Equal([]string{"https://app.example.com"}, res.Header["Access-Control-Allow-Origin"])
+		require.NoError(res.Body.Close())
+	}()
+
+	// test replay fixtures of one RAML resource requested with different URI parameters
+	func() {
+		conf := &Config{
+			Proxy:       upstream.URL,
+			FixturesDir: dir,
+			Record:      true,
+		}
+
+		rootdoc, err := ramlParser.ParseFile("../example/uri-parameters.raml")
+		require.NoError(err)
+
+		mock, err := newMocker(conf, rootdoc)
+		require.NoError(err)
+		ts := httptest.NewServer(mock)
+		defer ts.Close()
+		require.NotNil(ts)
+
+		paths := []string{"/users/1", "/users/2"}
+
+		mock.admin.setProxy("/users/{id}", true)
+		for _, path := range paths {
+			res, err := client.Get(ts.URL + path)
+			require.NoError(err)
+			require.EqualValues(http.StatusOK, res.StatusCode)
+			require.NoError(res.Body.Close())
+		}
+		mock.admin.setProxy("/users/{id}", false)
+
+		conf.Proxy = ""
+		conf.Record = false
+		conf.Replay = true
+
+		for _, path := range paths {
+			res, err := client.Get(ts.URL + path)
+			require.NoError(err)
+			require.EqualValues(http.StatusOK, res.StatusCode)
+			require.Equal("true", res.Header.Get("X-Upstream"))
+
+			body := getBodyValueForJSONType(t, res)
+			require.Equal(path, body.Map["path"].String)
+		}
+	}()
+}
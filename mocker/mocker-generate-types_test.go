@@ -0,0 +1,84 @@
+package mocker
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_GenerateTypes(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/generate-types.raml")
+	require.NoError(err)
+
+	conf := &Config{Seed: 9527}
+	handler, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	getEmployee := func() parser.Value {
+		req, err := http.NewRequest("GET", ts.URL+"/employee", nil)
+		require.NoError(err)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		return getBodyValueForJSONType(t, res)
+	}
+
+	// test generated object with inherited properties
+	func() {
+		body := getEmployee()
+		require.Equal(parser.TypeObject, body.Type)
+		for _, name := range []string{"name", "email", "level", "salary", "role", "joined", "updated", "tags"} {
+			require.Contains(body.Map, name)
+		}
+		require.Regexp(regexp.MustCompile(`^[a-z]{3,6}@example\.com$`), body.Map["email"].String)
+		require.Contains([]string{"admin", "user"}, body.Map["role"].String)
+		require.NoError(checkValueType(*rootdoc.Types["Employee"], body, conf.checkValueOptions()...))
+
+		// test reproducible output with seed
+		require.Equal(body, getEmployee())
+	}()
+
+	// test generated array of union type
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/pets", nil)
+		require.NoError(err)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		data, err := ioutil.ReadAll(res.Body)
+		require.NoError(err)
+		err = res.Body.Close()
+		require.NoError(err)
+
+		pets := []map[string]interface{}{}
+		err = json.Unmarshal(data, &pets)
+		require.NoError(err)
+		require.Len(pets, 2)
+		for _, pet := range pets {
+			_, meow := pet["meow"]
+			_, bark := pet["bark"]
+			require.True(meow || bark)
+		}
+	}()
+}
@@ -0,0 +1,96 @@
+package mocker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_InvalidExamples(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/multiple-responses.raml")
+	require.NoError(err)
+
+	conf := &Config{}
+	require.Empty(checkResponseExamples(conf, rootdoc))
+
+	// make example drift from declared type
+	body := rootdoc.Resources["/user"].Methods["get"].Responses[http.StatusOK].Bodies[mimeTypeJSON]
+	require.NotNil(body)
+	body.Example.Value, err = parser.NewValue(map[string]interface{}{
+		"name": 9527,
+	})
+	require.NoError(err)
+
+	// test check examples against declared types
+	func() {
+		violations := checkResponseExamples(conf, rootdoc)
+		require.Len(violations, 1)
+		require.Equal("GET", violations[0].Method)
+		require.Equal("/user", violations[0].Resource)
+		require.EqualValues(http.StatusOK, violations[0].Code)
+		require.Len(violations[0].Violations, 1)
+		require.Equal("/name", violations[0].Violations[0].Pointer)
+		require.Equal(locationResponseBody, violations[0].Violations[0].Location)
+	}()
+
+	// test refuse to start in strict mode
+	func() {
+		require.NoError(reportResponseExamples(conf, rootdoc))
+
+		conf.Strict = true
+		defer func() {
+			conf.Strict = false
+		}()
+
+		err := reportResponseExamples(conf, rootdoc)
+		require.Error(err)
+		require.True(ErrorResponseExamplesNotConform1.Match(err))
+	}()
+
+	handler, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	// test invalid example served if not strict
+	func() {
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+	}()
+
+	// test invalid example refused in strict mode
+	func() {
+		conf.Strict = true
+		defer func() {
+			conf.Strict = false
+		}()
+
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusInternalServerError, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Contains(body.Map, "violations")
+
+		req, err := http.NewRequest("GET", ts.URL+"/user", nil)
+		require.NoError(err)
+		req.Header.Set(headerMockStatus, "404")
+
+		res, err = client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusNotFound, res.StatusCode)
+	}()
+}
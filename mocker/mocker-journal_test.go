@@ -0,0 +1,118 @@
+package mocker
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_Journal(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/organisation-api.raml")
+	require.NoError(err)
+
+	mock, err := newMocker(&Config{}, rootdoc)
+	require.NoError(err)
+	defer mock.Close()
+	ts := mock.NewTestServer()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	postOrganisation := func(body string) {
+		req, err := http.NewRequest("POST", ts.URL+"/organisation", bytes.NewBufferString(body))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeJSON)
+		req.Header.Set("UserID", "SWED-123")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.NoError(res.Body.Close())
+	}
+
+	// record requests
+	func() {
+		postOrganisation(`{"name":"Doe Enterprise"}`)
+		postOrganisation(`{"address":"nowhere"}`)
+
+		res, err := client.Get(ts.URL + "/organisation")
+		require.NoError(err)
+		require.NoError(res.Body.Close())
+
+		res, err = client.Get(ts.URL + "/unknown")
+		require.NoError(err)
+		require.NoError(res.Body.Close())
+	}()
+
+	// test query journal by Go API
+	func() {
+		entries := mock.Journal(JournalFilter{})
+		require.Len(entries, 4)
+
+		entries = mock.Journal(JournalFilter{Resource: "/organisation", Method: "post"})
+		require.Len(entries, 2)
+		require.Equal("/organisation", entries[0].Resource)
+		require.Equal(`{"name":"Doe Enterprise"}`, entries[0].Body)
+		require.Equal("SWED-123", entries[0].Headers.Get("UserID"))
+		require.True(entries[0].Valid)
+		require.EqualValues(http.StatusOK, entries[0].Status)
+		require.False(entries[1].Valid)
+		require.NotEmpty(entries[1].Errors)
+		require.EqualValues(http.StatusBadRequest, entries[1].Status)
+
+		entries = mock.Journal(JournalFilter{Method: "GET"})
+		require.Len(entries, 2)
+		require.Empty(entries[1].Resource)
+		require.EqualValues(http.StatusNotFound, entries[1].Status)
+	}()
+
+	// test query journal by HTTP
+	func() {
+		res, err := client.Get(ts.URL + adminPrefix + "/requests?resource=/organisation&method=GET")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		entries := []JournalEntry{}
+		err = json.NewDecoder(res.Body).Decode(&entries)
+		require.NoError(err)
+		require.NoError(res.Body.Close())
+		require.Len(entries, 1)
+		require.EqualValues(201, entries[0].Status)
+	}()
+
+	// test clear journal by HTTP
+	func() {
+		req, err := http.NewRequest("DELETE", ts.URL+adminPrefix+"/requests", nil)
+		require.NoError(err)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusNoContent, res.StatusCode)
+
+		require.Empty(mock.Journal(JournalFilter{}))
+	}()
+}
+
+func Test_Journal_Bounded(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	journal := newJournal(2)
+	for _, method := range []string{"GET", "POST", "PUT"} {
+		journal.add(JournalEntry{Method: method})
+	}
+
+	entries := journal.list(JournalFilter{})
+	require.Len(entries, 2)
+	require.Equal("POST", entries[0].Method)
+	require.Equal("PUT", entries[1].Method)
+}
@@ -0,0 +1,96 @@
+package mocker
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_Mocker(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	mock1, err := New(Config{
+		RAMLFile: "../example/stateful-api.raml",
+		Stateful: true,
+	})
+	require.NoError(err)
+	defer mock1.Close()
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/stateful-api.raml")
+	require.NoError(err)
+
+	mock2, err := NewFromRootDocument(Config{Stateful: true}, rootdoc)
+	require.NoError(err)
+	defer mock2.Close()
+
+	ts1 := mock1.NewTestServer()
+	ts2 := mock2.NewTestServer()
+
+	client := http.DefaultClient
+
+	countBooks := func(url string) int {
+		res, err := client.Get(url + "/book")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		books := []map[string]interface{}{}
+		require.NoError(json.NewDecoder(res.Body).Decode(&books))
+		require.NoError(res.Body.Close())
+		return len(books)
+	}
+
+	// test mockers keep independent records and journals
+	func() {
+		req, err := http.NewRequest("POST", ts1.URL+"/book", bytes.NewBufferString(`{"title":"Concurrency in Go"}`))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeJSON)
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusCreated, res.StatusCode)
+		require.NoError(res.Body.Close())
+
+		require.Equal(3, countBooks(ts1.URL))
+		require.Equal(2, countBooks(ts2.URL))
+
+		require.Len(mock1.Journal(JournalFilter{}), 2)
+		require.Len(mock2.Journal(JournalFilter{}), 1)
+		require.Len(mock1.Journal(JournalFilter{Method: "POST"}), 1)
+
+		mock1.ClearJournal()
+		require.Empty(mock1.Journal(JournalFilter{}))
+		require.Len(mock2.Journal(JournalFilter{}), 1)
+	}()
+
+	// test test servers closed by mocker
+	func() {
+		require.NoError(mock1.Close())
+		_, err := client.Get(ts1.URL + "/book")
+		require.Error(err)
+		require.Equal(2, countBooks(ts2.URL))
+	}()
+
+	// test RAML file not found
+	func() {
+		_, err := New(Config{RAMLFile: "../example/not-exist.raml"})
+		require.Error(err)
+	}()
+
+	// test routes of RAML document failed to build
+	func() {
+		rootdoc, err := ramlParser.ParseFile("../example/root-parameter.raml")
+		require.NoError(err)
+		rootdoc.Resources["/{name}"] = rootdoc.Resources["/{id}"]
+
+		mock, err := NewFromRootDocument(Config{}, rootdoc)
+		require.Error(err)
+		require.True(ErrorBuildRoutesFailed1.Match(err))
+		require.Nil(mock)
+	}()
+}
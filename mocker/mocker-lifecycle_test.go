@@ -0,0 +1,126 @@
+package mocker
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MockServer_GracefulShutdown(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(listener.Close())
+
+	conf := &Config{
+		RAMLFile: "../example/multiple-responses.raml",
+		Port:     int64(port),
+		Delay:    200 * time.Millisecond,
+	}
+
+	rootdoc, err := parseRAMLFile(conf)
+	require.NoError(err)
+
+	handler, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	server, err := newServer(conf, handler)
+	require.NoError(err)
+
+	signals := make(chan os.Signal, 1)
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- serve([]*http.Server{server}, signals)
+	}()
+
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	for i := 0; i < 50; i++ {
+		var conn net.Conn
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			require.NoError(conn.Close())
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NoError(err)
+
+	// test in-flight request done after signal received
+	responses := make(chan int, 1)
+	go func() {
+		res, err := http.Get("http://" + addr + "/user")
+		if err != nil {
+			responses <- 0
+			return
+		}
+		res.Body.Close()
+		responses <- res.StatusCode
+	}()
+	time.Sleep(50 * time.Millisecond)
+	signals <- syscall.SIGTERM
+
+	require.EqualValues(http.StatusOK, <-responses)
+	require.NoError(<-stopped)
+
+	_, err = http.Get("http://" + addr + "/user")
+	require.Error(err)
+}
+
+func Test_MockServer_ReloadSwap(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	conf := &Config{
+		RAMLFile: "../example/multiple-responses.raml",
+	}
+
+	rootdoc, err := parseRAMLFile(conf)
+	require.NoError(err)
+
+	handler, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	// test requests during reload always see a complete route table
+	wg := sync.WaitGroup{}
+	codes := make(chan int, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := http.Get(ts.URL + "/user")
+			if err != nil {
+				codes <- 0
+				return
+			}
+			res.Body.Close()
+			codes <- res.StatusCode
+		}()
+		if i%10 == 0 {
+			handler.bind(rootdoc)
+		}
+	}
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		require.EqualValues(http.StatusOK, code)
+	}
+
+	// test reload from RAML file
+	require.NoError(handler.reload())
+	res, err := http.Get(ts.URL + "/user")
+	require.NoError(err)
+	require.EqualValues(http.StatusOK, res.StatusCode)
+	require.NoError(res.Body.Close())
+}
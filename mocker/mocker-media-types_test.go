@@ -0,0 +1,89 @@
+package mocker
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_MediaTypes(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/media-types.raml")
+	require.NoError(err)
+
+	handler, err := newMocker(&Config{}, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	getReport := func(accept string) (*http.Response, []byte) {
+		req, err := http.NewRequest("GET", ts.URL+"/report", nil)
+		require.NoError(err)
+		req.Header.Set("Accept", accept)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(err)
+		err = res.Body.Close()
+		require.NoError(err)
+		return res, body
+	}
+
+	// test text example served as-is
+	func() {
+		res, body := getReport("text/csv")
+		require.Contains(res.Header.Get("Content-Type"), "text/csv")
+		require.Contains(string(body), "name,value")
+	}()
+
+	// test binary example included from file
+	func() {
+		res, body := getReport("application/pdf")
+		require.Equal("application/pdf", res.Header.Get("Content-Type"))
+
+		expected, err := ioutil.ReadFile("../example/files/sample.pdf")
+		require.NoError(err)
+		require.Equal(expected, body)
+	}()
+
+	// test JSON example with vendor MIME type
+	func() {
+		res, body := getReport("application/vnd.api+json")
+		require.Contains(res.Header.Get("Content-Type"), "application/vnd.api+json")
+		require.JSONEq(`{"name":"report"}`, string(body))
+	}()
+
+	// test post vendor JSON request body
+	func() {
+		req, err := http.NewRequest("POST", ts.URL+"/report", bytes.NewBufferString(`{"name":"foo"}`))
+		require.NoError(err)
+		req.Header.Set("Content-Type", "application/vnd.api+json")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusCreated, res.StatusCode)
+		require.Contains(res.Header.Get("Content-Type"), "text/plain")
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(err)
+		err = res.Body.Close()
+		require.NoError(err)
+		require.Equal("created", string(body))
+	}()
+}
@@ -0,0 +1,143 @@
+package mocker
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MockServer_Mount(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	billing, err := New(Config{
+		RAMLFile:   "../example/stateful-api.raml",
+		PathPrefix: "/billing/",
+		Stateful:   true,
+		AdminToken: "billing-secret",
+	})
+	require.NoError(err)
+	defer billing.Close()
+	require.Equal("/billing", billing.PathPrefix())
+
+	users, err := New(Config{
+		RAMLFile:   "../example/multiple-responses.raml",
+		PathPrefix: "users",
+	})
+	require.NoError(err)
+	defer users.Close()
+
+	handler, err := NewMux(billing, users)
+	require.NoError(err)
+
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	// test resources of each RAML file served under its path prefix
+	func() {
+		res, err := client.Get(ts.URL + "/billing/book")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		books := []map[string]interface{}{}
+		require.NoError(json.NewDecoder(res.Body).Decode(&books))
+		require.NoError(res.Body.Close())
+		require.Len(books, 2)
+
+		res, err = client.Get(ts.URL + "/users/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		body := getBodyValueForJSONType(t, res)
+		require.Contains(body.Map, "name")
+	}()
+
+	// test resources not served under path prefix of another RAML file
+	func() {
+		for _, path := range []string{"/users/book", "/billing/user", "/book", "/unknown/user"} {
+			res, err := client.Get(ts.URL + path)
+			require.NoError(err)
+			require.EqualValues(http.StatusNotFound, res.StatusCode, path)
+			require.NoError(res.Body.Close())
+		}
+	}()
+
+	// test config and state kept per mocker
+	func() {
+		req, err := http.NewRequest("POST", ts.URL+"/billing/book", bytes.NewBufferString(`{"title":"Concurrency in Go"}`))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeJSON)
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusCreated, res.StatusCode)
+		require.NoError(res.Body.Close())
+
+		require.Len(billing.Journal(JournalFilter{Method: "POST"}), 1)
+		require.Empty(users.Journal(JournalFilter{Method: "POST"}))
+
+		res, err = client.Get(ts.URL + "/billing" + adminPrefix + "/routes")
+		require.NoError(err)
+		require.EqualValues(http.StatusUnauthorized, res.StatusCode)
+		require.NoError(res.Body.Close())
+
+		res, err = client.Get(ts.URL + "/users" + adminPrefix + "/routes")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		routes := []adminRoute{}
+		require.NoError(json.NewDecoder(res.Body).Decode(&routes))
+		require.NoError(res.Body.Close())
+		for _, route := range routes {
+			require.Equal("/user", route.Resource)
+		}
+	}()
+
+	// test path prefix mounted twice
+	func() {
+		other, err := New(Config{
+			RAMLFile:   "../example/organisation-api.raml",
+			PathPrefix: "/users/",
+		})
+		require.NoError(err)
+		defer other.Close()
+
+		_, err = NewMux(billing, users, other)
+		require.Error(err)
+		require.True(ErrorPathPrefixConflict2.Match(err))
+	}()
+
+	// test mockers on different ports served by different servers
+	func() {
+		other, err := New(Config{
+			RAMLFile: "../example/organisation-api.raml",
+			Port:     4001,
+		})
+		require.NoError(err)
+		defer other.Close()
+
+		servers, err := newServers([]*Mocker{billing, users, other})
+		require.NoError(err)
+		require.Len(servers, 2)
+		require.Equal(":0", servers[0].Addr)
+		require.Equal(":4001", servers[1].Addr)
+	}()
+
+	// test mockers on the same port with different TLS settings
+	func() {
+		other, err := New(Config{
+			RAMLFile:      "../example/organisation-api.raml",
+			PathPrefix:    "/other",
+			TLSSelfSigned: true,
+		})
+		require.NoError(err)
+		defer other.Close()
+
+		_, err = newServers([]*Mocker{billing, users, other})
+		require.Error(err)
+		require.True(ErrorTLSPortConflict1.Match(err))
+	}()
+}
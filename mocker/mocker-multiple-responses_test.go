@@ -0,0 +1,140 @@
+package mocker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_MultipleResponses(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/multiple-responses.raml")
+	require.NoError(err)
+
+	handler, err := newMocker(&Config{}, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	// test default status code
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/user", nil)
+		require.NoError(err)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Contains(body.Map, "name")
+	}()
+
+	// test acceptable MIME type
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/user", nil)
+		require.NoError(err)
+		req.Header.Set("Accept", "text/html;q=0.9, application/*;q=0.5")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Contains(body.Map, "name")
+	}()
+
+	// test not acceptable MIME type
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/user", nil)
+		require.NoError(err)
+		req.Header.Set("Accept", "text/html")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusNotAcceptable, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Contains(body.Map, "mimetypes")
+	}()
+
+	// test status code selected by header
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/user", nil)
+		require.NoError(err)
+		req.Header.Set(headerMockStatus, "404")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusNotFound, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Contains(body.Map, "message")
+		require.Equal("user not found", body.Map["message"].String)
+	}()
+
+	// test status code selected by query parameter
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/user?"+queryMockStatus+"=500", nil)
+		require.NoError(err)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusInternalServerError, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("internal server error", body.Map["message"].String)
+	}()
+
+	// test status code not declared
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/user", nil)
+		require.NoError(err)
+		req.Header.Set(headerMockStatus, "418")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusNotFound, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Contains(body.Map, "status")
+		require.Len(body.Map["status"].Array, 3)
+	}()
+
+	// test invalid status code
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/user", nil)
+		require.NoError(err)
+		req.Header.Set(headerMockStatus, "error")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusBadRequest, res.StatusCode)
+
+		err = res.Body.Close()
+		require.NoError(err)
+	}()
+
+	// test response without body
+	func() {
+		req, err := http.NewRequest("DELETE", ts.URL+"/user", nil)
+		require.NoError(err)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusNoContent, res.StatusCode)
+
+		err = res.Body.Close()
+		require.NoError(err)
+	}()
+}
@@ -20,7 +20,9 @@ func Test_MockServer_OrganisationAPI(t *testing.T) {
 	rootdoc, err := ramlParser.ParseFile("../example/organisation-api.raml")
 	require.NoError(err)
 
-	ts := httptest.NewServer(engineFromRootDocument(nil, rootdoc))
+	handler, err := newMocker(&Config{}, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
 	defer ts.Close()
 	require.NotNil(ts)
 
@@ -38,6 +40,51 @@ func Test_MockServer_OrganisationAPI(t *testing.T) {
 		body := getBodyValueForJSONType(t, res)
 		require.Equal(parser.TypeObject, body.Type)
 		require.Contains(body.Map, "name")
+		require.Equal("Acme", body.Map["name"].String)
+	}()
+
+	// test get resource with example selected by header
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/organisation", nil)
+		require.NoError(err)
+		req.Header.Set(headerMockExample, "softwareCorp")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(201, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Contains(body.Map, "name")
+		require.Equal("Software Corp", body.Map["name"].String)
+	}()
+
+	// test get resource with example selected by query parameter
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/organisation?"+queryMockExample+"=acme", nil)
+		require.NoError(err)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(201, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Contains(body.Map, "name")
+		require.Equal("Acme", body.Map["name"].String)
+	}()
+
+	// test get resource with non-exist example
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/organisation", nil)
+		require.NoError(err)
+		req.Header.Set(headerMockExample, "error")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusNotFound, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Contains(body.Map, "examples")
+		require.Len(body.Map["examples"].Array, 2)
 	}()
 
 	// test get non-exist resource
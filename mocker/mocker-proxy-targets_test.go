@@ -0,0 +1,149 @@
+package mocker
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func newNamedServer(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Server", name)
+		w.Header().Set("X-Token", r.Header.Get("X-Token"))
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func Test_MockServer_ProxyTargets(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	defaultServer := newNamedServer("default")
+	defer defaultServer.Close()
+	userServer := newNamedServer("user")
+	defer userServer.Close()
+	billingServer := newNamedServer("billing")
+	defer billingServer.Close()
+	orderServer := newNamedServer("order")
+	defer orderServer.Close()
+
+	conf := &Config{
+		Proxy:     defaultServer.URL,
+		Resources: BuildResourcesMap([]string{"/status"}),
+		ProxyTargets: []ProxyTarget{
+			{
+				URL:       userServer.URL,
+				Resources: []string{"/user"},
+			},
+			{
+				URL:        billingServer.URL,
+				Annotation: "billing",
+			},
+			{
+				URL:        orderServer.URL,
+				PathPrefix: "/orders",
+				Headers: map[string]string{
+					"X-Token": "secret",
+				},
+			},
+		},
+	}
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/proxy-targets.raml")
+	require.NoError(err)
+
+	handler, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	proxyTo := func(path string) *http.Response {
+		res, err := client.Get(ts.URL + path)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.NoError(res.Body.Close())
+		return res
+	}
+
+	// test route by RAML resource
+	func() {
+		res := proxyTo("/user")
+		require.Equal("user", res.Header.Get("X-Server"))
+	}()
+
+	// test route by RAML annotation
+	func() {
+		res := proxyTo("/invoice")
+		require.Equal("billing", res.Header.Get("X-Server"))
+	}()
+
+	// test route by path prefix with injected headers
+	func() {
+		res := proxyTo("/orders/1")
+		require.Equal("order", res.Header.Get("X-Server"))
+		require.Equal("secret", res.Header.Get("X-Token"))
+
+		res = proxyTo("/ordersx")
+		require.Equal("default", res.Header.Get("X-Server"))
+	}()
+
+	// test mocked resource not proxied
+	func() {
+		res, err := client.Get(ts.URL + "/status")
+		require.NoError(err)
+		require.Empty(res.Header.Get("X-Server"))
+
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("ok", body.Map["status"].String)
+	}()
+}
+
+func Test_LoadProxyTargets(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	dir, err := ioutil.TempDir("", "go-raml-mocker-targets")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "targets.yaml")
+	err = ioutil.WriteFile(path, []byte(`
+targets:
+  - name: users
+    url: http://users.backend:8080
+    prefix: /users
+    headers:
+      X-Token: secret
+    tls:
+      insecureSkipVerify: true
+`), 0644)
+	require.NoError(err)
+
+	targets, err := LoadProxyTargets(path)
+	require.NoError(err)
+	require.Len(targets, 1)
+	require.Equal("users", targets[0].Name)
+	require.Equal("/users", targets[0].PathPrefix)
+	require.Equal("secret", targets[0].Headers["X-Token"])
+	require.True(targets[0].TLS.InsecureSkipVerify)
+
+	target, err := ParseProxyTarget("/orders=http://orders.backend:8080")
+	require.NoError(err)
+	require.Equal("/orders", target.PathPrefix)
+	require.Equal("http://orders.backend:8080", target.URL)
+
+	_, err = ParseProxyTarget("http://orders.backend:8080")
+	require.Error(err)
+}
@@ -0,0 +1,144 @@
+package mocker
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_Proxy(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	release := make(chan bool)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/echo":
+			w.Header().Set("Content-Type", mimeTypeJSON)
+			w.Header().Set("Connection", "X-Upstream-Hop")
+			w.Header().Set("X-Upstream-Hop", "true")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"host":    r.Host,
+				"headers": r.Header,
+			})
+		case "/api/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: first\n\n"))
+			w.(http.Flusher).Flush()
+			<-release
+			w.Write([]byte("data: second\n\n"))
+		case "/api/slow":
+			time.Sleep(2 * time.Second)
+		}
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	require.NoError(err)
+
+	conf := &Config{
+		Proxy:        upstream.URL + "/api",
+		ProxyTimeout: 1,
+	}
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/multiple-responses.raml")
+	require.NoError(err)
+
+	handler, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	// test forwarded headers
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/echo", nil)
+		require.NoError(err)
+		req.Header.Set("Connection", "X-Client-Hop")
+		req.Header.Set("X-Client-Hop", "true")
+		req.Header.Set("X-Custom", "custom")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.Empty(res.Header.Get("X-Upstream-Hop"))
+
+		body := struct {
+			Host    string      `json:"host"`
+			Headers http.Header `json:"headers"`
+		}{}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		require.NoError(err)
+		require.NoError(res.Body.Close())
+
+		require.Equal(upstreamURL.Host, body.Host)
+		require.Equal("custom", body.Headers.Get("X-Custom"))
+		require.Empty(body.Headers.Get("X-Client-Hop"))
+		require.NotEmpty(body.Headers.Get("X-Forwarded-For"))
+		require.Equal(req.URL.Host, body.Headers.Get("X-Forwarded-Host"))
+		require.Equal("http", body.Headers.Get("X-Forwarded-Proto"))
+	}()
+
+	// test streaming server-sent events
+	func() {
+		res, err := client.Get(ts.URL + "/events")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		defer res.Body.Close()
+
+		reader := bufio.NewReader(res.Body)
+		line, err := reader.ReadString('\n')
+		require.NoError(err)
+		require.Equal("data: first\n", line)
+
+		close(release)
+	}()
+
+	// test gateway timeout
+	func() {
+		res, err := client.Get(ts.URL + "/slow")
+		require.NoError(err)
+		require.EqualValues(http.StatusGatewayTimeout, res.StatusCode)
+		require.NoError(res.Body.Close())
+	}()
+
+	// test bad gateway
+	func() {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		conf.Proxy = closed.URL
+		closed.Close()
+
+		res, err := client.Get(ts.URL + "/echo")
+		require.NoError(err)
+		require.EqualValues(http.StatusBadGateway, res.StatusCode)
+		require.NoError(res.Body.Close())
+	}()
+
+	// test transports kept per mocker and closed by Close of mocker
+	func() {
+		other, err := newMocker(&Config{Proxy: upstream.URL}, rootdoc)
+		require.NoError(err)
+		defer other.Close()
+
+		transport, err := handler.proxyTransport(ProxyTarget{URL: upstream.URL})
+		require.NoError(err)
+		otherTransport, err := other.proxyTransport(ProxyTarget{URL: upstream.URL})
+		require.NoError(err)
+		require.True(transport != otherTransport)
+
+		require.NoError(handler.Close())
+		require.Empty(handler.transports)
+		require.Len(other.transports, 1)
+	}()
+}
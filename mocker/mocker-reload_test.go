@@ -0,0 +1,135 @@
+package mocker
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MockServer_ReloadFailed(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	raw, err := ioutil.ReadFile("../example/multiple-responses.raml")
+	require.NoError(err)
+
+	dir, err := ioutil.TempDir("", "go-raml-mocker-reload")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	ramlFile := filepath.Join(dir, "api.raml")
+	require.NoError(ioutil.WriteFile(ramlFile, raw, 0644))
+
+	conf := &Config{
+		RAMLFile: ramlFile,
+	}
+
+	rootdoc, err := parseRAMLFile(conf)
+	require.NoError(err)
+
+	handler, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	getStatus := func() ReloadStatus {
+		res, err := client.Get(ts.URL + adminPrefix + "/status")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		status := ReloadStatus{}
+		require.NoError(json.NewDecoder(res.Body).Decode(&status))
+		require.NoError(res.Body.Close())
+		return status
+	}
+
+	// test status of loaded document
+	func() {
+		status := getStatus()
+		require.Equal(ramlFile, status.RAMLFile)
+		require.False(status.LoadedAt.IsZero())
+		require.Empty(status.Error)
+	}()
+
+	// test broken RAML file keep previous document
+	func() {
+		require.NoError(ioutil.WriteFile(ramlFile, []byte("#%RAML 1.0\ntitle: [\n"), 0644))
+
+		res, err := client.Post(ts.URL+adminPrefix+"/reload", mimeTypeJSON, nil)
+		require.NoError(err)
+		require.EqualValues(http.StatusInternalServerError, res.StatusCode)
+		require.NoError(res.Body.Close())
+
+		res, err = client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.NotEmpty(res.Header.Get("X-Mock-Reload-Error"))
+		require.NoError(res.Body.Close())
+
+		status := getStatus()
+		require.NotEmpty(status.Error)
+		require.NotNil(status.FailedAt)
+	}()
+
+	// test resource missing in fixed RAML file keep previous document
+	func() {
+		require.NoError(ioutil.WriteFile(ramlFile, raw, 0644))
+		conf.Resources = BuildResourcesMap([]string{"/missing"})
+		err := handler.reload()
+		require.Error(err)
+		require.True(ErrorResourceNotFound1.Match(err))
+		conf.Resources = nil
+
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.NoError(res.Body.Close())
+	}()
+
+	// test error cleared after reload succeeded
+	func() {
+		require.NoError(handler.reload())
+
+		res, err := client.Get(ts.URL + "/user")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.Empty(res.Header.Get("X-Mock-Reload-Error"))
+		require.NoError(res.Body.Close())
+
+		require.Empty(getStatus().Error)
+	}()
+
+	// test concurrent reloads by watcher and administrative API swap in whole document
+	func() {
+		errs := make(chan error, 8)
+		for i := 0; i < cap(errs); i++ {
+			go func(i int) {
+				if i%2 == 0 {
+					errs <- handler.reload()
+					return
+				}
+				res, err := client.Post(ts.URL+adminPrefix+"/reload", mimeTypeJSON, nil)
+				if err == nil {
+					err = res.Body.Close()
+				}
+				errs <- err
+			}(i)
+		}
+		for i := 0; i < cap(errs); i++ {
+			require.NoError(<-errs)
+		}
+
+		require.Empty(getStatus().Error)
+		require.Len(handler.admin.listRoutes(handler.currentBinding().routes), 2)
+		resource, _ := handler.currentResourceMatcher().match("/user")
+		require.Equal("/user", resource)
+	}()
+}
@@ -20,7 +20,10 @@ func Test_MockServer_RequestBodyGet(t *testing.T) {
 	rootdoc, err := ramlParser.ParseFile("../example/request-body-get.raml")
 	require.NoError(err)
 
-	ts := httptest.NewServer(engineFromRootDocument(nil, rootdoc))
+	conf := &Config{}
+	handler, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
 	defer ts.Close()
 	require.NotNil(ts)
 
@@ -108,13 +111,9 @@ func Test_MockServer_RequestBodyGet(t *testing.T) {
 	}()
 
 	func() {
-		backupOpts := checkValueOptions[:]
-		checkValueOptions = []parser.CheckValueOption{
-			parser.CheckValueOptionAllowIntegerToBeNumber(true),
-			parser.CheckValueOptionAllowRequiredPropertyToBeEmpty(true),
-		}
+		conf.AllowRequiredPropertyToBeEmpty = true
 		defer func() {
-			checkValueOptions = backupOpts[:]
+			conf.AllowRequiredPropertyToBeEmpty = false
 		}()
 
 		params := url.Values{
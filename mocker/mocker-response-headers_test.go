@@ -0,0 +1,52 @@
+package mocker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_ResponseHeaders(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/response-headers.raml")
+	require.NoError(err)
+
+	handler, err := newMocker(&Config{}, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	// test example, generated and array headers
+	func() {
+		res, err := client.Get(ts.URL + "/items")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.Equal("2", res.Header.Get("X-Total-Count"))
+		require.NotEmpty(res.Header.Get("ETag"))
+		require.Equal([]string{
+			`</items?page=2>; rel="next"`,
+			`</items?page=5>; rel="last"`,
+		}, res.Header["Link"])
+		require.Empty(res.Header.Get("X-Request-Id"))
+		require.Contains(res.Header.Get("Content-Type"), "application/json")
+	}()
+
+	// test header of response without body
+	func() {
+		res, err := client.Post(ts.URL+"/items", "application/json", nil)
+		require.NoError(err)
+		require.EqualValues(http.StatusCreated, res.StatusCode)
+		require.Equal("/items/3", res.Header.Get("Location"))
+	}()
+}
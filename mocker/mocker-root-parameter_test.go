@@ -0,0 +1,53 @@
+package mocker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MockServer_RootParameter(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	mock, err := New(Config{
+		RAMLFile: "../example/root-parameter.raml",
+	})
+	require.NoError(err)
+	defer mock.Close()
+
+	ts := httptest.NewServer(mock)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	// test top-level URI parameter resource served
+	func() {
+		res, err := client.Get(ts.URL + "/123")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("Bob", body.Map["name"].String)
+	}()
+
+	// test administrative API not conflicted with top-level URI parameter
+	func() {
+		res, err := client.Get(ts.URL + adminPrefix + "/routes")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		routes := []adminRoute{}
+		require.NoError(json.NewDecoder(res.Body).Decode(&routes))
+		require.NoError(res.Body.Close())
+		require.Len(routes, 1)
+		require.Equal("/{id}", routes[0].Resource)
+
+		res, err = client.Get(ts.URL + adminPrefix)
+		require.NoError(err)
+		require.EqualValues(http.StatusNotFound, res.StatusCode)
+		require.NoError(res.Body.Close())
+	}()
+}
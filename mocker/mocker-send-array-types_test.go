@@ -20,7 +20,9 @@ func Test_MockServer_SendArrayTypes(t *testing.T) {
 	rootdoc, err := ramlParser.ParseFile("../example/send-array-types.raml")
 	require.NoError(err)
 
-	ts := httptest.NewServer(engineFromRootDocument(nil, rootdoc))
+	handler, err := newMocker(&Config{}, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
 	defer ts.Close()
 	require.NotNil(ts)
 
@@ -0,0 +1,226 @@
+package mocker
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_Stateful(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/stateful-api.raml")
+	require.NoError(err)
+
+	handler, err := newMocker(&Config{Stateful: true}, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	listBooks := func() []map[string]interface{} {
+		res, err := client.Get(ts.URL + "/book")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		books := []map[string]interface{}{}
+		err = json.NewDecoder(res.Body).Decode(&books)
+		require.NoError(err)
+		require.NoError(res.Body.Close())
+		return books
+	}
+
+	// test collection seeded from examples
+	func() {
+		books := listBooks()
+		require.Len(books, 2)
+		require.Equal("Go in Action", books[0]["title"])
+	}()
+
+	// test create record
+	func() {
+		req, err := http.NewRequest("POST", ts.URL+"/book", bytes.NewBufferString(`{"title":"Concurrency in Go"}`))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeJSON)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusCreated, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.EqualValues(3, body.Map["id"].Integer)
+		require.Equal("Concurrency in Go", body.Map["title"].String)
+
+		require.Len(listBooks(), 3)
+	}()
+
+	// test create invalid record
+	func() {
+		req, err := http.NewRequest("POST", ts.URL+"/book", bytes.NewBufferString(`{"author":"nobody"}`))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeJSON)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusBadRequest, res.StatusCode)
+
+		require.Len(listBooks(), 3)
+	}()
+
+	// test create record with existing id
+	func() {
+		req, err := http.NewRequest("POST", ts.URL+"/book", bytes.NewBufferString(`{"id":1,"title":"Duplicated"}`))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeJSON)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusConflict, res.StatusCode)
+		require.NoError(res.Body.Close())
+
+		books := listBooks()
+		require.Len(books, 3)
+		require.Equal("Go in Action", books[0]["title"])
+	}()
+
+	// test read record
+	func() {
+		res, err := client.Get(ts.URL + "/book/3")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("Concurrency in Go", body.Map["title"].String)
+	}()
+
+	// test patch record
+	func() {
+		req, err := http.NewRequest("PATCH", ts.URL+"/book/3", bytes.NewBufferString(`{"author":"Katherine Cox-Buday"}`))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeJSON)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("Concurrency in Go", body.Map["title"].String)
+		require.Equal("Katherine Cox-Buday", body.Map["author"].String)
+	}()
+
+	// test replace record
+	func() {
+		req, err := http.NewRequest("PUT", ts.URL+"/book/3", bytes.NewBufferString(`{"title":"Black Hat Go"}`))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeJSON)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("Black Hat Go", body.Map["title"].String)
+		require.NotContains(body.Map, "author")
+	}()
+
+	// test delete record
+	func() {
+		req, err := http.NewRequest("DELETE", ts.URL+"/book/3", nil)
+		require.NoError(err)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusNoContent, res.StatusCode)
+
+		res, err = client.Get(ts.URL + "/book/3")
+		require.NoError(err)
+		require.EqualValues(http.StatusNotFound, res.StatusCode)
+	}()
+
+	// test static example still selectable
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/book/3", nil)
+		require.NoError(err)
+		req.Header.Set(headerMockStatus, "200")
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+
+		body := getBodyValueForJSONType(t, res)
+		require.Equal("Go in Action", body.Map["title"].String)
+	}()
+
+	// test nested collection keyed by parent URI parameter
+	func() {
+		req, err := http.NewRequest("POST", ts.URL+"/book/1/review", bytes.NewBufferString(`{"text":"Good","tags":["go"]}`))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeJSON)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusCreated, res.StatusCode)
+		require.NoError(res.Body.Close())
+
+		countReviews := func(book string) int {
+			res, err := client.Get(ts.URL + "/book/" + book + "/review")
+			require.NoError(err)
+			require.EqualValues(http.StatusOK, res.StatusCode)
+			reviews := []map[string]interface{}{}
+			require.NoError(json.NewDecoder(res.Body).Decode(&reviews))
+			require.NoError(res.Body.Close())
+			return len(reviews)
+		}
+		require.Equal(1, countReviews("1"))
+		require.Equal(0, countReviews("2"))
+	}()
+
+	// test records kept after RAML document bound again
+	func() {
+		require.NoError(handler.bind(rootdoc))
+
+		res, err := client.Get(ts.URL + "/book/1/review")
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		reviews := []map[string]interface{}{}
+		require.NoError(json.NewDecoder(res.Body).Decode(&reviews))
+		require.NoError(res.Body.Close())
+		require.Len(reviews, 1)
+		require.Equal("Good", reviews[0]["text"])
+	}()
+
+	// test route override does not disable stateful store
+	func() {
+		handler.admin.setOverride(routeOverride{
+			RouteOverride: RouteOverride{Method: "GET", Resource: "/book/{id}", Status: 200},
+		})
+		defer handler.admin.resetOverrides()
+
+		res, err := client.Get(ts.URL + "/book/3")
+		require.NoError(err)
+		require.EqualValues(http.StatusNotFound, res.StatusCode)
+		require.NoError(res.Body.Close())
+	}()
+
+	// test reset state
+	func() {
+		req, err := http.NewRequest("DELETE", ts.URL+adminPrefix+"/state", nil)
+		require.NoError(err)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusNoContent, res.StatusCode)
+
+		require.Len(listBooks(), 2)
+	}()
+}
@@ -0,0 +1,98 @@
+package mocker
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_TLSSelfSigned(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(listener.Close())
+
+	conf := &Config{
+		Port:          int64(port),
+		TLSSelfSigned: true,
+	}
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/organisation-api.raml")
+	require.NoError(err)
+
+	handler, err := newMocker(conf, rootdoc)
+	require.NoError(err)
+	server, err := newServer(conf, handler)
+	require.NoError(err)
+	require.NotNil(server.TLSConfig)
+	go server.ListenAndServeTLS("", "")
+	defer server.Close()
+
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	var conn *tls.Conn
+	for i := 0; i < 50; i++ {
+		if conn, err = tls.Dial("tcp", addr, &tls.Config{
+			InsecureSkipVerify: true,
+			NextProtos:         []string{"h2"},
+		}); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NoError(err)
+
+	// test HTTP/2 negotiated and certificate issued for localhost
+	func() {
+		defer conn.Close()
+		state := conn.ConnectionState()
+		require.Equal("h2", state.NegotiatedProtocol)
+		require.Len(state.PeerCertificates, 1)
+		require.NoError(state.PeerCertificates[0].VerifyHostname("localhost"))
+		require.NoError(state.PeerCertificates[0].VerifyHostname("127.0.0.1"))
+	}()
+
+	// test HTTPS request
+	func() {
+		client := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		}
+		res, err := client.Get("https://" + addr + "/organisation")
+		require.NoError(err)
+		defer res.Body.Close()
+		require.EqualValues(http.StatusOK, res.StatusCode)
+	}()
+}
+
+func Test_TLSConfig(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	conf := &Config{}
+	tlsConf, err := conf.tlsConfig()
+	require.NoError(err)
+	require.Nil(tlsConf)
+
+	conf = &Config{TLSCert: "cert.pem"}
+	_, err = conf.tlsConfig()
+	require.Error(err)
+	require.True(ErrorTLSKeyPairRequired.Match(err))
+
+	conf = &Config{TLSCert: "not-exist-cert.pem", TLSKey: "not-exist-key.pem"}
+	_, err = conf.tlsConfig()
+	require.Error(err)
+	require.True(ErrorTLSLoadKeyPair2.Match(err))
+}
@@ -0,0 +1,81 @@
+package mocker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_URIParameters(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/uri-parameters.raml")
+	require.NoError(err)
+
+	handler, err := newMocker(&Config{}, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	getViolations := func(path string, code int) []violation {
+		res, err := client.Get(ts.URL + path)
+		require.NoError(err)
+		require.EqualValues(code, res.StatusCode)
+
+		result := struct {
+			Violations []violation `json:"violations"`
+		}{}
+		if code != http.StatusOK {
+			err = json.NewDecoder(res.Body).Decode(&result)
+			require.NoError(err)
+		}
+		require.NoError(res.Body.Close())
+		return result.Violations
+	}
+
+	// test valid URI parameters
+	func() {
+		getViolations("/users/1", http.StatusOK)
+		getViolations("/users/1/posts/hello-world", http.StatusOK)
+		getViolations("/regions/eu", http.StatusOK)
+	}()
+
+	// test resource URI parameter
+	func() {
+		violations := getViolations("/users/abc", http.StatusNotFound)
+		require.Len(violations, 1)
+		require.Equal(locationURI, violations[0].Location)
+		require.Equal("/id", violations[0].Pointer)
+		require.Equal(parser.TypeInteger, violations[0].Expected)
+
+		violations = getViolations("/users/0", http.StatusNotFound)
+		require.Len(violations, 1)
+		require.Equal("minimum 1", violations[0].Expected)
+	}()
+
+	// test nested resource inherit URI parameter from parent resource
+	func() {
+		violations := getViolations("/users/abc/posts/Hello", http.StatusNotFound)
+		require.Len(violations, 2)
+		require.Equal("/id", violations[0].Pointer)
+		require.Equal("/slug", violations[1].Pointer)
+	}()
+
+	// test URI parameter declared in baseUriParameters
+	func() {
+		violations := getViolations("/regions/asia", http.StatusNotFound)
+		require.Len(violations, 1)
+		require.Equal("/region", violations[0].Pointer)
+	}()
+}
@@ -0,0 +1,118 @@
+package mocker
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_Validation(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/validation.raml")
+	require.NoError(err)
+
+	handler, err := newMocker(&Config{}, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	type validationError struct {
+		Error      string      `json:"error"`
+		Title      string      `json:"title"`
+		Status     int         `json:"status"`
+		Violations []violation `json:"violations"`
+	}
+
+	postPerson := func(query string, body string, header http.Header) (*http.Response, validationError) {
+		req, err := http.NewRequest("POST", ts.URL+"/person"+query, bytes.NewBufferString(body))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeJSON)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		result := validationError{}
+		if res.StatusCode == http.StatusBadRequest {
+			err = json.NewDecoder(res.Body).Decode(&result)
+			require.NoError(err)
+		}
+		require.NoError(res.Body.Close())
+		return res, result
+	}
+
+	pointers := func(result validationError) map[string]string {
+		found := map[string]string{}
+		for _, violation := range result.Violations {
+			found[violation.Location+violation.Pointer] = violation.Expected
+		}
+		return found
+	}
+
+	// test valid request
+	func() {
+		res, _ := postPerson("?limit=10", `{"name":"Bob","age":30,"tags":["a"],"role":"admin"}`, http.Header{
+			"X-Token": []string{"secret"},
+		})
+		require.EqualValues(http.StatusCreated, res.StatusCode)
+	}()
+
+	// test all violations reported in one response
+	func() {
+		res, result := postPerson("?limit=1000", `{"name":"B","age":-1,"email":"nobody","tags":["a","b","c"],"role":"root"}`, nil)
+		require.EqualValues(http.StatusBadRequest, res.StatusCode)
+		require.Contains(res.Header.Get("Content-Type"), mimeTypeJSON)
+		require.Equal(ErrorValidationFailed.New(nil).Error(), result.Error)
+
+		found := pointers(result)
+		require.Len(found, 7)
+		require.Equal("required", found["header/X-Token"])
+		require.Equal("maximum 100", found["query/limit"])
+		require.Equal("minLength 2", found["body/name"])
+		require.Equal("minimum 0", found["body/age"])
+		require.Contains(found, "body/email")
+		require.Equal("maxItems 2", found["body/tags"])
+		require.Contains(found, "body/role")
+	}()
+
+	// test type mismatch and missing property
+	func() {
+		res, result := postPerson("?limit=abc", `{"age":"old","tags":["a",1]}`, http.Header{
+			"X-Token": []string{"secret"},
+		})
+		require.EqualValues(http.StatusBadRequest, res.StatusCode)
+
+		found := pointers(result)
+		require.Equal(parser.TypeInteger, found["query/limit"])
+		require.Equal("required", found["body/name"])
+		require.Equal(parser.TypeInteger, found["body/age"])
+		require.Equal(parser.TypeString, found["body/tags/1"])
+	}()
+
+	// test problem+json
+	func() {
+		res, result := postPerson("", `{"name":"Bob","age":30}`, http.Header{
+			"Accept": []string{mimeTypeProblemJSON},
+		})
+		require.EqualValues(http.StatusBadRequest, res.StatusCode)
+		require.Contains(res.Header.Get("Content-Type"), mimeTypeProblemJSON)
+		require.EqualValues(http.StatusBadRequest, result.Status)
+		require.Equal(ErrorValidationFailed.New(nil).Error(), result.Title)
+		require.Len(result.Violations, 1)
+		require.Equal(locationHeader, result.Violations[0].Location)
+	}()
+}
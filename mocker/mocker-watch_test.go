@@ -0,0 +1,172 @@
+package mocker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RAMLDependencies(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	dir, err := ioutil.TempDir("", "go-raml-mocker-deps")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(err)
+
+	files := map[string]string{
+		"api/api.raml": `#%RAML 1.0
+title: Dependencies
+uses:
+  lib: ../shared/lib.raml
+
+types:
+  User: !include types/user.raml
+/user:
+  get:
+    responses:
+      200:
+        body:
+          application/json:
+            example: !include examples/user.json
+`,
+		"api/types/user.raml":     "#%RAML 1.0 DataType\ntype: !include ../schemas/user.json\n",
+		"api/schemas/user.json":   `{"properties": {"name": {"$ref": "name.json#/definitions/name"}, "id": {"$ref": "#/definitions/id"}}}`,
+		"api/schemas/name.json":   `{"definitions": {"name": {"type": "string"}}}`,
+		"api/examples/user.json":  `{"name": "user"}`,
+		"shared/lib.raml":         "#%RAML 1.0 Library\ntypes:\n  Item: !include item.raml\n",
+		"shared/item.raml":        "#%RAML 1.0 DataType\ntype: object\n",
+		"shared/not-related.raml": "#%RAML 1.0 DataType\ntype: object\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	deps := ramlDependencies(filepath.Join(dir, "api/api.raml"))
+	sort.Strings(deps)
+	require.Equal([]string{
+		filepath.Join(dir, "api/api.raml"),
+		filepath.Join(dir, "api/examples/user.json"),
+		filepath.Join(dir, "api/schemas/name.json"),
+		filepath.Join(dir, "api/schemas/user.json"),
+		filepath.Join(dir, "api/types/user.raml"),
+		filepath.Join(dir, "shared/item.raml"),
+		filepath.Join(dir, "shared/lib.raml"),
+	}, deps)
+}
+
+func Test_Watch(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	dir, err := ioutil.TempDir("", "go-raml-mocker-watch")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(err)
+
+	ramlFile := filepath.Join(dir, "api", "api.raml")
+	includeFile := filepath.Join(dir, "types", "user.raml")
+	require.NoError(os.MkdirAll(filepath.Dir(ramlFile), 0755))
+	require.NoError(os.MkdirAll(filepath.Dir(includeFile), 0755))
+	ramlContent := []byte("#%RAML 1.0\ntitle: Watch\ntypes:\n  User: !include ../types/user.raml\n")
+	require.NoError(ioutil.WriteFile(ramlFile, ramlContent, 0644))
+	require.NoError(ioutil.WriteFile(includeFile, []byte("type: object\n"), 0644))
+
+	var count int32
+	watcher, err := watch(ramlFile, func() error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	})
+	require.NoError(err)
+	defer watcher.Close()
+
+	settle := func() int32 {
+		time.Sleep(watchDebounce + 500*time.Millisecond)
+		return atomic.LoadInt32(&count)
+	}
+
+	// test events of included file outside RAML directory are debounced
+	func() {
+		for i := 0; i < 3; i++ {
+			require.NoError(ioutil.WriteFile(includeFile, []byte("type: object\n"), 0644))
+			time.Sleep(10 * time.Millisecond)
+		}
+		require.EqualValues(1, settle())
+	}()
+
+	// test files not referenced by RAML file are ignored
+	func() {
+		require.NoError(ioutil.WriteFile(filepath.Join(dir, "api", "notes.txt"), []byte("note"), 0644))
+		require.EqualValues(1, settle())
+	}()
+
+	// test atomic save by renaming
+	func() {
+		tmpFile := filepath.Join(dir, "api", ".api.raml.tmp")
+		require.NoError(ioutil.WriteFile(tmpFile, ramlContent, 0644))
+		require.NoError(os.Rename(tmpFile, ramlFile))
+		require.EqualValues(2, settle())
+	}()
+
+	// test removed file
+	func() {
+		require.NoError(os.Remove(includeFile))
+		require.EqualValues(3, settle())
+	}()
+
+	// test dependency in directory not existed yet
+	func() {
+		laterFile := filepath.Join(dir, "later", "types", "user.raml")
+		require.NoError(ioutil.WriteFile(ramlFile, []byte("#%RAML 1.0\ntitle: Watch\ntypes:\n  User: !include ../later/types/user.raml\n"), 0644))
+		require.EqualValues(4, settle())
+
+		require.NoError(os.MkdirAll(filepath.Dir(laterFile), 0755))
+		require.NoError(ioutil.WriteFile(laterFile, []byte("type: object\n"), 0644))
+		require.EqualValues(5, settle())
+
+		require.NoError(ioutil.WriteFile(laterFile, []byte("type: object\n"), 0644))
+		require.EqualValues(6, settle())
+	}()
+}
+
+func Test_WatchDirectory(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	dir, err := ioutil.TempDir("", "go-raml-mocker-watch-dir")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	var count int32
+	watcher, err := watch(dir, func() error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	})
+	require.NoError(err)
+	defer watcher.Close()
+
+	settle := func() int32 {
+		time.Sleep(watchDebounce + 500*time.Millisecond)
+		return atomic.LoadInt32(&count)
+	}
+
+	// test new directory is watched
+	subdir := filepath.Join(dir, "types")
+	require.NoError(os.Mkdir(subdir, 0755))
+	created := settle()
+	require.EqualValues(1, created)
+
+	require.NoError(ioutil.WriteFile(filepath.Join(subdir, "user.raml"), []byte("type: object\n"), 0644))
+	require.EqualValues(created+1, settle())
+}
@@ -0,0 +1,103 @@
+package mocker
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_MockServer_XMLBody(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	ramlParser := parser.NewParser()
+	require.NotNil(ramlParser)
+
+	rootdoc, err := ramlParser.ParseFile("../example/xml-body.raml")
+	require.NoError(err)
+
+	handler, err := newMocker(&Config{}, rootdoc)
+	require.NoError(err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	require.NotNil(ts)
+
+	client := http.DefaultClient
+
+	// test JSON example rendered to XML by xml facets
+	func() {
+		req, err := http.NewRequest("GET", ts.URL+"/person", nil)
+		require.NoError(err)
+		req.Header.Set("Accept", mimeTypeXML)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusOK, res.StatusCode)
+		require.Contains(res.Header.Get("Content-Type"), mimeTypeXML)
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(err)
+		err = res.Body.Close()
+		require.NoError(err)
+
+		require.Contains(string(body), `<person id="1">`)
+		require.Contains(string(body), `<name>Bob</name>`)
+		require.Contains(string(body), `<tags><item>a</item><item>b</item></tags>`)
+	}()
+
+	// test post XML request body and XML example served verbatim
+	func() {
+		req, err := http.NewRequest("POST", ts.URL+"/person", bytes.NewBufferString(
+			`<person id="2"><name>Alice</name><tags><item>c</item></tags></person>`,
+		))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeXML)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusCreated, res.StatusCode)
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(err)
+		err = res.Body.Close()
+		require.NoError(err)
+
+		require.Equal(`<person id="1"><name>Bob</name></person>`, strings.TrimSpace(string(body)))
+	}()
+
+	// test post XML request body with wrong attribute type
+	func() {
+		req, err := http.NewRequest("POST", ts.URL+"/person", bytes.NewBufferString(
+			`<person id="abc"><name>Alice</name></person>`,
+		))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeXML)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusBadRequest, res.StatusCode)
+
+		err = res.Body.Close()
+		require.NoError(err)
+	}()
+
+	// test post malformed XML request body
+	func() {
+		req, err := http.NewRequest("POST", ts.URL+"/person", bytes.NewBufferString(`<person>`))
+		require.NoError(err)
+		req.Header.Set("Content-Type", mimeTypeXML)
+
+		res, err := client.Do(req)
+		require.NoError(err)
+		require.EqualValues(http.StatusBadRequest, res.StatusCode)
+
+		err = res.Body.Close()
+		require.NoError(err)
+	}()
+}
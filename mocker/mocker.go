@@ -3,7 +3,6 @@ package mocker
 import (
 	"encoding/json"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"regexp"
 	"strings"
@@ -27,85 +26,15 @@ var (
 )
 
 const (
-	mimeTypeJSON = "application/json"
-	mimeTypeBMP  = "image/bmp"
-	mimeTypeGIF  = "image/gif"
-	mimeTypeJPEG = "image/jpeg"
-	mimeTypePNG  = "image/png"
+	mimeTypeJSON          = "application/json"
+	mimeTypeForm          = "application/x-www-form-urlencoded"
+	mimeTypeMultipartForm = "multipart/form-data"
 )
 
-func engineFromRootDocument(prevEngine *gin.Engine, rootdoc parser.RootDocument) *gin.Engine {
-	if _, err := json.Marshal(rootdoc); err != nil {
-		errutil.Trace(err)
-	}
-
-	if prevEngine != nil {
-		prevEngine.ResetRoutes()
-		bindRootDocument(prevEngine, rootdoc)
-		return prevEngine
-	}
-
-	router := gin.Default()
-	router.Use(gin.ErrorLogger())
-	bindRootDocument(router, rootdoc)
-	router.NoRoute(proxyRoute)
-	router.NoMethod(proxyRoute)
-	return router
-}
-
-func proxyRoute(c *gin.Context) {
-	if config.Proxy == "" {
-		return
-	}
-
-	logger.Debugf("Proxy to: %s %s", c.Request.Method, config.Proxy+c.Request.RequestURI)
-
-	if c.Request.Header.Get("Upgrade") == "websocket" {
-		if err := proxyWebSocket(c); err != nil {
-			logger.Debugln(err)
-			return
-		}
-		return
-	}
-
-	client := http.Client{}
-
-	req, err := http.NewRequest(c.Request.Method, config.Proxy+c.Request.RequestURI, c.Request.Body)
-	if err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
-		return
-	}
-
-	req.Header = c.Request.Header
-
-	resp, err := client.Do(req)
-	if err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
-		return
-	}
-	defer resp.Body.Close()
-
-	code := resp.StatusCode
-	contentType := resp.Header.Get("Content-Type")
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
-		return
-	}
-	outputHeader := c.Writer.Header()
-	for name, headers := range resp.Header {
-		for _, header := range headers {
-			outputHeader.Add(name, header)
-		}
-	}
-	c.Header("Access-Control-Allow-Origin", "*")
-	c.Data(code, contentType, data)
-}
-
-func proxyWebSocket(c *gin.Context) (err error) {
+func proxyWebSocket(c *gin.Context, proxyURL string) (err error) {
 	regexpProto := regexp.MustCompile(`^http`)
 	origin := c.Request.Header.Get("Origin")
-	wsurl := regexpProto.ReplaceAllString(config.Proxy+c.Request.RequestURI, "ws")
+	wsurl := regexpProto.ReplaceAllString(proxyURL+c.Request.RequestURI, "ws")
 	header := http.Header{}
 	header.Set("Origin", origin)
 	wssrc, _, err := websocket.DefaultDialer.Dial(wsurl, header)
@@ -178,56 +107,17 @@ func copyWebSocketMessage(dst *websocket.Conn, src *websocket.Conn) (err error)
 	return nil
 }
 
-func checkValueType(apiType parser.APIType, ivalue interface{}) error {
+func checkValueType(apiType parser.APIType, ivalue interface{}, options ...parser.CheckValueOption) error {
 	value, err := parser.NewValue(ivalue)
 	if err != nil {
 		return err
 	}
-	if err = parser.CheckValueAPIType(apiType, value, checkValueOptions...); err != nil {
-		return err
-	}
-	return nil
-}
-
-func checkHeader(req *http.Request, header parser.Property) error {
-	headerName := header.Name
-	headerValue := req.Header.Get(headerName)
-	if header.Required && headerValue == "" {
-		return ErrorHeaderRequired1.New(nil, headerName)
-	}
-	if err := checkValueType(header.APIType, headerValue); err != nil {
+	if err = parser.CheckValueAPIType(apiType, value, options...); err != nil {
 		return err
 	}
 	return nil
 }
 
-func checkTrait(trait parser.Trait, c *gin.Context, requestBody parser.Value) error {
-	for _, header := range trait.Headers.Slice() {
-		if err := checkHeader(c.Request, *header); err != nil {
-			return err
-		}
-	}
-
-	for _, qp := range trait.QueryParameters.Slice() {
-		name := qp.Name
-		param := getParam(c, name, requestBody)
-		if qp.Required && param.IsEmpty() {
-			return ErrorQueryParameterRequired1.New(nil, name)
-		}
-		if err := checkValueType(qp.APIType, param); err != nil {
-			return err
-		}
-	}
-
-	for _, inherit := range trait.Is {
-		if err := checkTrait(*inherit, c, requestBody); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
 func getParam(c *gin.Context, name string, requestBody parser.Value) parser.Value {
 	if param, exist := c.Params.Get(name); exist {
 		result, err := parser.NewValue(param)
@@ -250,72 +140,161 @@ func getParam(c *gin.Context, name string, requestBody parser.Value) parser.Valu
 	return parser.Value{}
 }
 
-func bindRoute(
+func (t *Mocker) bindRoute(
 	router gin.IRouter,
 	methodName string,
 	path string,
-	code int,
-	mimetype string,
 	method parser.Method,
-	responseBody parser.Body,
+	res *stateResource,
+	uriParams []*parser.Property,
+	types parser.APITypes,
+	order exampleOrder,
 	istraits ...parser.IsTraits,
 ) {
-	var outputFunc func(c *gin.Context, code int, data interface{})
-
-	switch mimetype {
-	case mimeTypeJSON:
-		outputFunc = outputJSON
-	case mimeTypeBMP, mimeTypeGIF, mimeTypeJPEG, mimeTypePNG:
-		outputFunc = outputData
-	default:
-		errutil.Trace(ErrorUnsupportedMIMEType1.New(nil, mimetype))
-		return
+	for _, response := range method.Responses {
+		if response == nil {
+			continue
+		}
+		for mimetype := range response.Bodies {
+			if getOutputFunc(mimetype) == nil {
+				errutil.Trace(ErrorUnsupportedMIMEType1.New(nil, mimetype))
+			}
+		}
 	}
 
 	router.Handle(methodName, path, func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
+		c.Set(contextKeyResource, toRAMLResource(path))
+
+		if t.admin.isProxy(path) {
+			t.proxyRoute(c)
+			return
+		}
+
+		if t.config.Replay && t.serveFixture(c) {
+			return
+		}
+
+		exposeResponseHeaders(c, method)
+		t.applyOverride(c, methodName, path)
 
+		validator := newValidator(types, t.config)
+		for _, param := range uriParams {
+			validator.validateURIParameter(c, *param)
+		}
 		for _, header := range method.Headers.Slice() {
-			if err := checkHeader(c.Request, *header); err != nil {
-				c.AbortWithError(http.StatusBadRequest, err)
-				return
-			}
+			validator.validateHeader(locationHeader, c.Request.Header, *header)
 		}
 
 		requestBody := parser.Value{}
-		if methodBody, exist := method.Bodies[mimetype]; exist {
+		if methodBody := selectRequestBody(c, method.Bodies); methodBody != nil && isStructuredMIMEType(c.ContentType()) {
 			var err error
-			if requestBody, err = parseRequestBody(c, methodBody.APIType); err != nil {
-				c.AbortWithError(http.StatusBadRequest, ErrorBindFailed.New(err))
-				return
-			}
-			if err := checkValueType(methodBody.APIType, requestBody); err != nil {
-				c.AbortWithError(http.StatusBadRequest, err)
-				return
+			if requestBody, err = parseRequestBody(c, methodBody.APIType, types); err != nil {
+				validator.add(locationBody, "", mimetypeWithoutParams(c.ContentType()), nil, ErrorBindFailed.New(err).Error())
+			} else {
+				validator.validateBody(locationBody, methodBody.APIType, requestBody)
 			}
 		}
 
+		for _, qp := range method.QueryParameters.Slice() {
+			validator.validateQueryParameter(c, *qp, requestBody)
+		}
+
 		for _, istrait := range istraits {
 			for _, trait := range istrait {
-				if err := checkTrait(*trait, c, requestBody); err != nil {
-					c.AbortWithError(http.StatusBadRequest, err)
-					return
-				}
+				validator.validateTrait(c, *trait, requestBody)
 			}
 		}
 
-		if !responseBody.Examples.IsEmpty() {
-			for _, example := range responseBody.Examples {
-				outputFunc(c, code, example.Value)
+		if len(validator.violations) > 0 {
+			abortValidationFailed(c, validator.violations)
+			return
+		}
+
+		if t.isStateful(c, res) {
+			t.serveState(c, res, method, requestBody)
+			return
+		}
+
+		code, response, err := selectResponse(c, method.Responses)
+		if err != nil {
+			abortStatusCodeNotFound(c, method.Responses, err)
+			return
+		}
+
+		mimetype, responseBody, err := negotiateResponseBody(c, response)
+		if err != nil {
+			abortNotAcceptable(c, response, err)
+			return
+		}
+		outputFunc := getOutputFunc(mimetype)
+		if outputFunc == nil {
+			c.AbortWithError(http.StatusInternalServerError, ErrorUnsupportedMIMEType1.New(nil, mimetype))
+			return
+		}
+
+		names := order.names(exampleKey(methodName, path, code, mimetype), responseBody)
+		example, err := selectExample(c, responseBody, names, types, t.config.Seed)
+		if err != nil {
+			abortExampleNotFound(c, names, err)
+			return
+		}
+
+		if t.config.Strict {
+			if violations := checkResponseValue(t.config, mimetype, responseBody, types, example); len(violations) > 0 {
+				abortResponseNotConform(c, violations)
 				return
 			}
 		}
 
-		outputFunc(c, code, responseBody.Example.Value)
+		writeResponseHeaders(c, response, types, t.config.Seed)
+		outputFunc(c, code, mimetype, responseBody.APIType, types, example)
 	})
 }
 
-func parseRequestBody(c *gin.Context, apiType parser.APIType) (reqbody parser.Value, err error) {
+// selectRequestBody return the method body matched request content type,
+// fallback to JSON body if request content type is not declared
+func selectRequestBody(c *gin.Context, bodies parser.Bodies) *parser.Body {
+	if body, exist := bodies[c.ContentType()]; exist && body != nil {
+		return body
+	}
+	if body, exist := bodies[mimeTypeJSON]; exist && body != nil {
+		return body
+	}
+	return nil
+}
+
+// isStructuredMIMEType return true if request body of mimetype can be parsed for validation,
+// text and binary request bodies are passed without validation
+func isStructuredMIMEType(mimetype string) bool {
+	switch mimetypeWithoutParams(mimetype) {
+	case "", mimeTypeForm, mimeTypeMultipartForm:
+		return true
+	}
+	return isJSONMIMEType(mimetype) || isXMLMIMEType(mimetype)
+}
+
+func parseRequestBody(c *gin.Context, apiType parser.APIType, types parser.APITypes) (reqbody parser.Value, err error) {
+	if isXMLMIMEType(c.ContentType()) {
+		node, err := decodeXML(c.Request.Body)
+		if err != nil {
+			if err == io.EOF {
+				return parser.NewValue(map[string]interface{}{})
+			}
+			return reqbody, err
+		}
+		return parser.NewValue(xmlNodeValue(types, apiType, node))
+	}
+
+	if c.Request.Method != "GET" && isJSONMIMEType(c.ContentType()) {
+		mapbody := map[string]interface{}{}
+		if err = json.NewDecoder(c.Request.Body).Decode(&mapbody); err != nil {
+			if err != io.EOF {
+				return
+			}
+		}
+		return parser.NewValue(mapbody)
+	}
+
 	if c.Request.Method != "GET" {
 		mapbody := map[string]interface{}{}
 		if err = c.Bind(&mapbody); err != nil {
@@ -332,69 +311,35 @@ func parseRequestBody(c *gin.Context, apiType parser.APIType) (reqbody parser.Va
 	return parser.NewValueWithAPIType(apiType, c.Request.Form)
 }
 
-func bindDefaultResponse(
-	router gin.IRouter,
-	methodName string,
-	path string,
-	code int,
-	method parser.Method,
-	istraits ...parser.IsTraits,
-) {
-	mimetype := mimeTypeJSON
-	responseBody := parser.Body{}
-	bindRoute(router, methodName, path, code, mimetype, method, responseBody, istraits...)
-}
+// bindRootDocument bind routes of RAML document, return bound routes
+func (t *Mocker) bindRootDocument(router gin.IRouter, rootdoc parser.RootDocument) []adminRoute {
+	stateRes := stateResources(rootdoc)
+	order := loadExampleOrder(t.config.RAMLFile)
+	routes := []adminRoute{}
 
-func bindRootDocument(router gin.IRouter, rootdoc parser.RootDocument) {
 	for ramlPath, resource := range rootdoc.Resources {
-		if !isNeedToBindResource(ramlPath) {
+		if !t.config.isNeedToBindResource(ramlPath) {
 			continue
 		}
 		ginPath := toGinResource(ramlPath)
+		uriParams := resourceURIParameters(rootdoc, ramlPath)
 
 		for name, method := range resource.Methods {
 			methodName := strings.ToUpper(name)
+			routes = append(routes, newAdminRoute(methodName, ginPath))
 			if method == nil {
-				bindDefaultResponse(router, methodName, ginPath, 200, parser.Method{}, resource.Is)
-				continue
-			}
-			if len(method.Responses) < 1 {
-				bindDefaultResponse(router, methodName, ginPath, 200, *method, resource.Is, method.Is)
+				t.bindRoute(router, methodName, ginPath, parser.Method{}, stateRes[ramlPath], uriParams, rootdoc.Types, order, resource.Is)
 				continue
 			}
-
-			for code, response := range method.Responses {
-				if response == nil {
-					bindDefaultResponse(router, methodName, ginPath, int(code), *method, resource.Is, method.Is)
-					continue
-				}
-
-				for mimetype, responseBody := range response.Bodies {
-					bindRoute(router, methodName, ginPath, int(code), mimetype, *method, *responseBody, resource.Is, method.Is)
-				}
-			}
+			t.bindRoute(router, methodName, ginPath, *method, stateRes[ramlPath], uriParams, rootdoc.Types, order, resource.Is, method.Is)
 		}
 	}
-
-	if config.Proxy != "" {
-		router.OPTIONS("/*path", func(c *gin.Context) {
-			if value := c.Request.Header.Get("Access-Control-Request-Method"); value != "" {
-				c.Header("Access-Control-Allow-Methods", value)
-			}
-
-			if value := c.Request.Header.Get("Access-Control-Request-Headers"); value != "" {
-				c.Header("Access-Control-Allow-Headers", value)
-			}
-
-			c.Header("Access-Control-Allow-Origin", "*")
-			c.Status(http.StatusOK)
-		})
-	}
+	return routes
 }
 
-func isNeedToBindResource(resourcePath string) bool {
-	if len(config.Resources) < 1 {
+func (t *Config) isNeedToBindResource(resourcePath string) bool {
+	if len(t.Resources) < 1 {
 		return true
 	}
-	return config.Resources[resourcePath]
+	return t.Resources[resourcePath]
 }
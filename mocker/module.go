@@ -1,72 +1,162 @@
 package mocker
 
 import (
+	"context"
 	"fmt"
-	"path"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/tsaikd/KDGoLib/futil"
-	"github.com/tsaikd/gin"
+	"github.com/tsaikd/KDGoLib/errutil"
 	"github.com/tsaikd/go-raml-parser/parser"
 	"github.com/tsaikd/go-raml-parser/parser/parserConfig"
 )
 
-// Start mock server
-func Start(conf Config) (err error) {
-	config = &conf
+// Start mock servers of configs, block until received SIGINT or SIGTERM and in-flight requests are done,
+// mock servers listening on the same port are served by one web server and routed by path prefix
+func Start(confs ...Config) (err error) {
+	mockers := []*Mocker{}
+	defer func() {
+		for _, mocker := range mockers {
+			errutil.Trace(mocker.Close())
+		}
+	}()
 
-	checkValueOptions = []parser.CheckValueOption{
-		parser.CheckValueOptionAllowIntegerToBeNumber(true),
-		parser.CheckValueOptionAllowRequiredPropertyToBeEmpty(config.AllowRequiredPropertyToBeEmpty),
-	}
+	for _, conf := range confs {
+		mocker, err := New(conf)
+		if err != nil {
+			return err
+		}
+		mockers = append(mockers, mocker)
 
-	if futil.IsDir(config.RAMLFile) {
-		watch(config.RAMLFile)
-	} else {
-		dir, _ := path.Split(config.RAMLFile)
-		watch(dir)
+		if err = mocker.watch(); err != nil {
+			errutil.Trace(err)
+		}
 	}
 
-	return reload()
-}
+	servers, err := newServers(mockers)
+	if err != nil {
+		return
+	}
 
-var checkValueOptions = []parser.CheckValueOption{
-	parser.CheckValueOptionAllowIntegerToBeNumber(true),
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(signals)
+	return serve(servers, signals)
 }
 
-// used for reset routes
-var router *gin.Engine
+// max duration to wait in-flight requests on shutdown
+const shutdownTimeout = 30 * time.Second
 
-func reload() (err error) {
+// parseRAMLFile parse RAML file in config and check resources in config
+func parseRAMLFile(conf *Config) (rootdoc parser.RootDocument, err error) {
 	ramlParser := parser.NewParser()
 
-	if err = ramlParser.Config(parserConfig.CheckRAMLVersion, config.CheckRAMLVersion); err != nil {
+	if err = ramlParser.Config(parserConfig.CheckRAMLVersion, conf.CheckRAMLVersion); err != nil {
 		return
 	}
-	if err = ramlParser.Config(parserConfig.CheckValueOptions, checkValueOptions); err != nil {
+	if err = ramlParser.Config(parserConfig.CheckValueOptions, conf.checkValueOptions()); err != nil {
 		return
 	}
-	if err = ramlParser.Config(parserConfig.CacheDirectory, config.CacheDir); err != nil {
+	if err = ramlParser.Config(parserConfig.CacheDirectory, conf.CacheDir); err != nil {
 		return
 	}
 
-	rootdoc, err := ramlParser.ParseFile(config.RAMLFile)
-	if err != nil {
+	if rootdoc, err = ramlParser.ParseFile(conf.RAMLFile); err != nil {
 		return
 	}
 
-	if err = checkConfigResource(rootdoc.Resources); err != nil {
+	if err = conf.checkResources(rootdoc.Resources); err != nil {
 		return
 	}
 
-	if router == nil {
-		addr := fmt.Sprintf(":%d", config.Port)
-		router = engineFromRootDocument(router, rootdoc)
-		if err = router.Run(addr); err != nil {
-			return
+	if err = reportResponseExamples(conf, rootdoc); err != nil {
+		return
+	}
+
+	return
+}
+
+// newServers return web servers of mockers, mockers of the same port are mounted on one server,
+// return error if TLS settings of mockers on the same port are different
+func newServers(mockers []*Mocker) (servers []*http.Server, err error) {
+	ports := []int64{}
+	portMockers := map[int64][]*Mocker{}
+	for _, mocker := range mockers {
+		port := mocker.config.Port
+		if first, exist := portMockers[port]; !exist {
+			ports = append(ports, port)
+		} else if !first[0].config.isSameTLS(mocker.config) {
+			return nil, ErrorTLSPortConflict1.New(nil, port)
 		}
-	} else {
-		router = engineFromRootDocument(router, rootdoc)
+		portMockers[port] = append(portMockers[port], mocker)
 	}
 
+	for _, port := range ports {
+		handler, err := NewMux(portMockers[port]...)
+		if err != nil {
+			return nil, err
+		}
+		server, err := newServer(portMockers[port][0].config, handler)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
+// newServer return web server of handler listening on port in config, with TLS config if HTTPS is enabled
+func newServer(conf *Config, handler http.Handler) (server *http.Server, err error) {
+	server = &http.Server{
+		Addr:    fmt.Sprintf(":%d", conf.Port),
+		Handler: handler,
+	}
+	if server.TLSConfig, err = conf.tlsConfig(); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// serve listen and serve requests until stopped by signal or any server failed,
+// then wait in-flight requests to be done in shutdown timeout
+func serve(servers []*http.Server, signals <-chan os.Signal) (err error) {
+	errs := make(chan error, len(servers))
+	for _, server := range servers {
+		go func(server *http.Server) {
+			errs <- listenAndServe(server)
+		}(server)
+	}
+
+	running := len(servers)
+	select {
+	case err = <-errs:
+		running--
+	case sig := <-signals:
+		logger.Infof("received %v, shutting down mock server", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, server := range servers {
+		if serr := server.Shutdown(ctx); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	for ; running > 0; running-- {
+		if serr := <-errs; serr != http.ErrServerClosed && err == nil {
+			err = serr
+		}
+	}
 	return
 }
+
+func listenAndServe(server *http.Server) (err error) {
+	if server.TLSConfig != nil {
+		// certificates are already loaded in TLS config
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServe()
+}
@@ -0,0 +1,71 @@
+package mocker
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+)
+
+// errors
+var (
+	ErrorPathPrefixConflict2 = errutil.NewFactory("path prefix %q of RAML file %q is already mounted")
+)
+
+// mux route requests to mockers mounted on different path prefixes of one server
+type mux struct {
+	mockers []*Mocker
+}
+
+// NewMux return handler of mockers routed by path prefix of mocker config, the longest prefix is matched first,
+// e.g. mockers of billing.raml on /billing and users.raml on /users
+func NewMux(mockers ...*Mocker) (http.Handler, error) {
+	if len(mockers) == 1 {
+		return mockers[0], nil
+	}
+
+	mounted := map[string]bool{}
+	result := &mux{}
+	for _, mocker := range mockers {
+		prefix := mocker.PathPrefix()
+		if mounted[prefix] {
+			return nil, ErrorPathPrefixConflict2.New(nil, prefix, mocker.config.RAMLFile)
+		}
+		mounted[prefix] = true
+		result.mockers = append(result.mockers, mocker)
+	}
+	sort.SliceStable(result.mockers, func(i, j int) bool {
+		return len(result.mockers[i].PathPrefix()) > len(result.mockers[j].PathPrefix())
+	})
+	return result, nil
+}
+
+func (t *mux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	for _, mocker := range t.mockers {
+		if hasPathPrefix(req.URL.Path, mocker.PathPrefix()) {
+			mocker.ServeHTTP(w, req)
+			return
+		}
+	}
+	http.NotFound(w, req)
+}
+
+// stripPathPrefix return shallow copy of request with path prefix removed from URL,
+// RAML resources and proxy targets see path relative to mounted prefix
+func stripPathPrefix(req *http.Request, prefix string) *http.Request {
+	result := new(http.Request)
+	*result = *req
+	result.URL = new(url.URL)
+	*result.URL = *req.URL
+	result.URL.Path = strings.TrimPrefix(req.URL.Path, prefix)
+	if result.URL.Path == "" {
+		result.URL.Path = "/"
+	}
+	if req.URL.RawPath != "" {
+		result.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, prefix)
+	}
+	result.RequestURI = result.URL.RequestURI()
+	return result
+}
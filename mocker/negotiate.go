@@ -0,0 +1,116 @@
+package mocker
+
+import (
+	"strconv"
+	"strings"
+)
+
+// mediaRange is one media range parsed from Accept header
+type mediaRange struct {
+	mainType string
+	subType  string
+	quality  float64
+}
+
+// parseAccept return media ranges of Accept header, invalid ranges are ignored
+func parseAccept(accept string) []mediaRange {
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mimetype := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mimetype == "" {
+			continue
+		}
+		if mimetype == "*" {
+			mimetype = "*/*"
+		}
+		types := strings.SplitN(mimetype, "/", 2)
+		if len(types) != 2 || types[0] == "" || types[1] == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || strings.ToLower(strings.TrimSpace(kv[0])) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+			quality = q
+		}
+
+		ranges = append(ranges, mediaRange{
+			mainType: types[0],
+			subType:  types[1],
+			quality:  quality,
+		})
+	}
+	return ranges
+}
+
+// match return how specific the media range matches mimetype, 0 if not matched
+func (t mediaRange) match(mimetype string) int {
+	types := strings.SplitN(strings.ToLower(mimetypeWithoutParams(mimetype)), "/", 2)
+	if len(types) != 2 {
+		return 0
+	}
+	switch {
+	case t.mainType == "*" && t.subType == "*":
+		return 1
+	case t.mainType == types[0] && t.subType == "*":
+		return 2
+	case t.mainType == types[0] && t.subType == types[1]:
+		return 3
+	}
+	return 0
+}
+
+// negotiateMIMEType return the offered MIME type most acceptable by Accept header,
+// offers should be sorted by server preference
+func negotiateMIMEType(accept string, offers []string) (mimetype string, ok bool) {
+	if len(offers) < 1 {
+		return "", false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+
+	ranges := parseAccept(accept)
+	if len(ranges) < 1 {
+		return offers[0], true
+	}
+
+	bestQuality := 0.0
+	bestSpecificity := 0
+	for _, offer := range offers {
+		quality := 0.0
+		specificity := 0
+		for _, r := range ranges {
+			if s := r.match(offer); s > specificity {
+				specificity = s
+				quality = r.quality
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		if quality > bestQuality || (quality == bestQuality && specificity > bestSpecificity) {
+			mimetype = offer
+			bestQuality = quality
+			bestSpecificity = specificity
+			ok = true
+		}
+	}
+	return
+}
+
+// mimetypeWithoutParams return MIME type without parameters, e.g. charset
+func mimetypeWithoutParams(mimetype string) string {
+	if idx := strings.Index(mimetype, ";"); idx >= 0 {
+		mimetype = mimetype[:idx]
+	}
+	return strings.TrimSpace(mimetype)
+}
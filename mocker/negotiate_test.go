@@ -0,0 +1,44 @@
+package mocker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_negotiateMIMEType(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	offers := []string{mimeTypeJSON, "image/png", mimeTypeXML}
+
+	mimetype, ok := negotiateMIMEType("", offers)
+	require.True(ok)
+	require.Equal(mimeTypeJSON, mimetype)
+
+	mimetype, ok = negotiateMIMEType("*/*", offers)
+	require.True(ok)
+	require.Equal(mimeTypeJSON, mimetype)
+
+	mimetype, ok = negotiateMIMEType("application/xml", offers)
+	require.True(ok)
+	require.Equal(mimeTypeXML, mimetype)
+
+	mimetype, ok = negotiateMIMEType("image/*", offers)
+	require.True(ok)
+	require.Equal("image/png", mimetype)
+
+	mimetype, ok = negotiateMIMEType("application/json;q=0.5, application/xml", offers)
+	require.True(ok)
+	require.Equal(mimeTypeXML, mimetype)
+
+	mimetype, ok = negotiateMIMEType("application/*;q=0.8, application/json;q=0.1", offers)
+	require.True(ok)
+	require.Equal(mimeTypeXML, mimetype)
+
+	mimetype, ok = negotiateMIMEType("text/html, */*;q=0", offers)
+	require.False(ok)
+
+	mimetype, ok = negotiateMIMEType("text/html", offers)
+	require.False(ok)
+}
@@ -3,10 +3,15 @@ package mocker
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/KDGoLib/futil"
 	"github.com/tsaikd/gin"
 	"github.com/tsaikd/go-raml-parser/parser"
 )
@@ -15,46 +20,156 @@ import (
 var (
 	ErrorUnsupportedOutputType1 = errutil.NewFactory("unsupported output type %T")
 	ErrorUnexpectedOutputType2  = errutil.NewFactory("output type mismatch, expected %q but got %q")
+	ErrorReadIncludeFile1       = errutil.NewFactory("read example include file %q failed")
 )
 
-func outputJSON(c *gin.Context, code int, data interface{}) {
+// example string referenced to a file, e.g. "!include files/report.pdf"
+const includeTag = "!include"
+
+type outputFunc func(c *gin.Context, code int, mimetype string, apiType parser.APIType, types parser.APITypes, data interface{})
+
+// getOutputFunc return output function for MIME type family, nil if MIME type is invalid
+func getOutputFunc(mimetype string) outputFunc {
+	if !strings.Contains(mimetype, "/") {
+		return nil
+	}
+	switch {
+	case isJSONMIMEType(mimetype):
+		return outputJSON
+	case isXMLMIMEType(mimetype):
+		return outputXML
+	default:
+		return outputData
+	}
+}
+
+// isJSONMIMEType return true if mimetype is JSON or with +json suffix, e.g. application/vnd.api+json
+func isJSONMIMEType(mimetype string) bool {
+	mimetype = strings.ToLower(mimetypeWithoutParams(mimetype))
+	return mimetype == mimeTypeJSON || strings.HasSuffix(mimetype, "+json")
+}
+
+// isTextMIMEType return true if mimetype is text/*
+func isTextMIMEType(mimetype string) bool {
+	return strings.HasPrefix(strings.ToLower(mimetype), "text/")
+}
+
+// contentTypeWithCharset return content type with utf-8 charset if charset not declared
+func contentTypeWithCharset(mimetype string) string {
+	if strings.Contains(strings.ToLower(mimetype), "charset=") {
+		return mimetype
+	}
+	return mimetype + "; charset=utf-8"
+}
+
+// isPretty return true if client request pretty output by query parameter
+func isPretty(c *gin.Context) bool {
 	pretty := false
 	if queryPretty, exist := c.GetQuery("pretty"); exist {
 		pretty, _ = strconv.ParseBool(queryPretty)
 	}
+	return pretty
+}
 
-	if pretty {
-		jsondata, err := json.MarshalIndent(data, "", "\t")
-		if err != nil {
-			c.AbortWithError(http.StatusBadRequest, err)
-			return
-		}
-		buffer := bytes.NewBuffer(jsondata)
-		if err = buffer.WriteByte('\n'); err != nil {
-			c.AbortWithError(http.StatusBadRequest, err)
-			return
-		}
-		c.Data(code, "", buffer.Bytes())
+func outputJSON(c *gin.Context, code int, mimetype string, apiType parser.APIType, types parser.APITypes, data interface{}) {
+	var jsondata []byte
+	var err error
+	if isPretty(c) {
+		jsondata, err = json.MarshalIndent(data, "", "\t")
 	} else {
-		c.JSON(code, data)
+		jsondata, err = json.Marshal(data)
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
 	}
+	buffer := bytes.NewBuffer(jsondata)
+	if err = buffer.WriteByte('\n'); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	c.Data(code, contentTypeWithCharset(mimetype), buffer.Bytes())
 }
 
-func outputData(c *gin.Context, code int, data interface{}) {
+// outputData output example as-is, used for text and binary MIME types
+func outputData(c *gin.Context, code int, mimetype string, apiType parser.APIType, types parser.APITypes, data interface{}) {
+	contentType := mimetype
+	if isTextMIMEType(mimetype) {
+		contentType = contentTypeWithCharset(mimetype)
+	}
+
 	switch data.(type) {
 	case []byte:
-		c.Data(code, "", data.([]byte))
+		c.Data(code, contentType, data.([]byte))
 		return
 	case parser.Value:
-		value := data.(parser.Value)
-		if value.Type != parser.TypeBinary {
-			c.AbortWithError(http.StatusInternalServerError, ErrorUnexpectedOutputType2.New(nil, "[]byte", value.Type))
+		raw, err := valueBytes(contextConfig(c).ramlDir(), data.(parser.Value))
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
 			return
 		}
-		c.Data(code, "", value.Binary)
+		c.Data(code, contentType, raw)
 		return
 	default:
 		c.AbortWithError(http.StatusInternalServerError, ErrorUnsupportedOutputType1.New(nil, data))
 		return
 	}
 }
+
+// valueBytes return raw content of example value,
+// string referenced to a file by include tag is replaced by the file content
+func valueBytes(dir string, value parser.Value) ([]byte, error) {
+	switch value.Type {
+	case parser.TypeBinary:
+		return value.Binary, nil
+	case parser.TypeString:
+		if path, ok := includeFilePath(dir, value.String); ok {
+			raw, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, ErrorReadIncludeFile1.New(err, path)
+			}
+			return raw, nil
+		}
+		return []byte(value.String), nil
+	default:
+		return json.Marshal(value)
+	}
+}
+
+// includeFilePath return file path of include tag, relative path is resolved from RAML file directory
+func includeFilePath(dir string, text string) (path string, ok bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, includeTag+" ") {
+		return "", false
+	}
+	path = strings.TrimSpace(strings.TrimPrefix(text, includeTag))
+	if path == "" || filepath.IsAbs(path) {
+		return path, path != ""
+	}
+	return filepath.Join(dir, path), true
+}
+
+// ramlDir return directory of RAML file in config
+func (t *Config) ramlDir() string {
+	if t.RAMLFile == "" {
+		return "."
+	}
+	if futil.IsDir(t.RAMLFile) {
+		return t.RAMLFile
+	}
+	return filepath.Dir(t.RAMLFile)
+}
+
+// formatText return text of generic JSON value, used for XML text and URI parameter
+func formatText(data interface{}) string {
+	switch value := data.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
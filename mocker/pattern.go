@@ -0,0 +1,88 @@
+package mocker
+
+import (
+	"math/rand"
+	"regexp/syntax"
+	"unicode"
+)
+
+// max repeat count for unbounded quantifiers, e.g. * +
+const patternMaxRepeat = 3
+
+// generatePattern return random string matched regular expression pattern
+func generatePattern(r *rand.Rand, pattern string) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	runes := []rune{}
+	generatePatternRegexp(r, re.Simplify(), &runes)
+	return string(runes), nil
+}
+
+func generatePatternRegexp(r *rand.Rand, re *syntax.Regexp, runes *[]rune) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		*runes = append(*runes, re.Rune...)
+	case syntax.OpCharClass:
+		*runes = append(*runes, randomRuneInClass(r, re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		*runes = append(*runes, randomAlphanumeric(r))
+	case syntax.OpCapture:
+		generatePatternRegexp(r, re.Sub[0], runes)
+	case syntax.OpStar:
+		generatePatternRepeat(r, re.Sub[0], 0, patternMaxRepeat, runes)
+	case syntax.OpPlus:
+		generatePatternRepeat(r, re.Sub[0], 1, patternMaxRepeat+1, runes)
+	case syntax.OpQuest:
+		generatePatternRepeat(r, re.Sub[0], 0, 1, runes)
+	case syntax.OpRepeat:
+		max := re.Max
+		if max < 0 {
+			max = re.Min + patternMaxRepeat
+		}
+		generatePatternRepeat(r, re.Sub[0], re.Min, max, runes)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			generatePatternRegexp(r, sub, runes)
+		}
+	case syntax.OpAlternate:
+		generatePatternRegexp(r, re.Sub[r.Intn(len(re.Sub))], runes)
+	}
+}
+
+func generatePatternRepeat(r *rand.Rand, re *syntax.Regexp, min int, max int, runes *[]rune) {
+	count := min
+	if max > min {
+		count += r.Intn(max - min + 1)
+	}
+	for i := 0; i < count; i++ {
+		generatePatternRegexp(r, re, runes)
+	}
+}
+
+// randomRuneInClass return random rune in character class ranges,
+// printable ASCII characters are preferred
+func randomRuneInClass(r *rand.Rand, ranges []rune) rune {
+	candidates := []rune{}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for c := ranges[i]; c <= ranges[i+1] && c < unicode.MaxASCII; c++ {
+			if unicode.IsPrint(c) {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[r.Intn(len(candidates))]
+	}
+	if len(ranges) > 0 {
+		return ranges[0]
+	}
+	return randomAlphanumeric(r)
+}
+
+const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func randomAlphanumeric(r *rand.Rand) rune {
+	return rune(alphanumeric[r.Intn(len(alphanumeric))])
+}
@@ -0,0 +1,28 @@
+package mocker
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generatePattern(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	r := rand.New(rand.NewSource(9527))
+	for _, pattern := range []string{
+		`^[A-Z]{4}-\d{3}$`,
+		`^(foo|bar)+baz?$`,
+		`^[^@\s]+@[a-z]+\.(com|org)$`,
+		`^\w{2,}\.txt$`,
+	} {
+		for i := 0; i < 10; i++ {
+			value, err := generatePattern(r, pattern)
+			require.NoError(err)
+			require.Regexp(regexp.MustCompile(pattern), value)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package mocker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/gin"
+)
+
+// errors
+var (
+	ErrorProxyInvalidURL1 = errutil.NewFactory("invalid proxy server URL %q")
+	ErrorProxyFailed1     = errutil.NewFactory("proxy to %q failed")
+	ErrorProxyTimeout1    = errutil.NewFactory("proxy to %q timeout")
+)
+
+// proxy default settings
+const (
+	proxyDefaultDialTimeout  = 10 * time.Second
+	proxyDefaultTimeout      = 30 * time.Second
+	proxyIdleConnTimeout     = 90 * time.Second
+	proxyMaxIdleConnsPerHost = 16
+	proxyBufferSize          = 32 * 1024
+)
+
+// hop-by-hop headers, they are not forwarded by proxy, RFC 7230 section 6.1
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// removeHopHeaders remove hop-by-hop headers and headers listed in Connection header
+func removeHopHeaders(header http.Header) {
+	for _, value := range header["Connection"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				header.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+}
+
+// proxyTimeouts return dial and response header timeout of proxy in config
+func (t *Config) proxyTimeouts() (dialTimeout time.Duration, timeout time.Duration) {
+	dialTimeout, timeout = proxyDefaultDialTimeout, proxyDefaultTimeout
+	if t.ProxyDialTimeout > 0 {
+		dialTimeout = time.Duration(t.ProxyDialTimeout) * time.Second
+	}
+	if t.ProxyTimeout > 0 {
+		timeout = time.Duration(t.ProxyTimeout) * time.Second
+	}
+	return
+}
+
+// proxyTransport return pooled transport of proxy target,
+// transports are shared by targets of mocker with the same options and closed by Close of mocker
+func (t *Mocker) proxyTransport(target ProxyTarget) (*http.Transport, error) {
+	dialTimeout, timeout := t.config.proxyTimeouts()
+	key := fmt.Sprintf("%v|%v|%+v", dialTimeout, timeout, target.TLS)
+
+	t.transportMutex.Lock()
+	defer t.transportMutex.Unlock()
+	if transport, exist := t.transports[key]; exist {
+		return transport, nil
+	}
+
+	tlsConfig, err := target.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		MaxIdleConnsPerHost:   proxyMaxIdleConnsPerHost,
+		IdleConnTimeout:       proxyIdleConnTimeout,
+		TLSHandshakeTimeout:   dialTimeout,
+		ResponseHeaderTimeout: timeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	t.transports[key] = transport
+	return transport, nil
+}
+
+// closeProxyTransports close idle connections of pooled transports, transports are created again on demand
+func (t *Mocker) closeProxyTransports() {
+	t.transportMutex.Lock()
+	defer t.transportMutex.Unlock()
+	for _, transport := range t.transports {
+		transport.CloseIdleConnections()
+	}
+	t.transports = map[string]*http.Transport{}
+}
+
+// proxyRequest return request to proxy target with forwarded and injected headers
+func proxyRequest(c *gin.Context, proxyTarget ProxyTarget, target *url.URL, body io.Reader) (*http.Request, error) {
+	outURL := *target
+	outURL.Path = singleJoiningSlash(target.Path, c.Request.URL.Path)
+	outURL.RawPath = ""
+	outURL.RawQuery = c.Request.URL.RawQuery
+
+	req, err := http.NewRequest(c.Request.Method, outURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(c.Request.Context())
+	req.ContentLength = c.Request.ContentLength
+
+	req.Header = http.Header{}
+	for name, values := range c.Request.Header {
+		req.Header[name] = append([]string{}, values...)
+	}
+	removeHopHeaders(req.Header)
+
+	if clientIP, _, err := net.SplitHostPort(c.Request.RemoteAddr); err == nil {
+		if prior := req.Header.Get("X-Forwarded-For"); prior != "" {
+			clientIP = prior + ", " + clientIP
+		}
+		req.Header.Set("X-Forwarded-For", clientIP)
+	}
+	if req.Header.Get("X-Forwarded-Host") == "" {
+		req.Header.Set("X-Forwarded-Host", c.Request.Host)
+	}
+	if req.Header.Get("X-Forwarded-Proto") == "" {
+		proto := "http"
+		if c.Request.TLS != nil {
+			proto = "https"
+		}
+		req.Header.Set("X-Forwarded-Proto", proto)
+	}
+	proxyTarget.injectHeaders(req.Header)
+	req.Host = target.Host
+	return req, nil
+}
+
+func singleJoiningSlash(a string, b string) string {
+	aslash := strings.HasSuffix(a, "/")
+	bslash := strings.HasPrefix(b, "/")
+	switch {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash:
+		return a + "/" + b
+	}
+	return a + b
+}
+
+// isTimeoutError return true if err is caused by timeout
+func isTimeoutError(err error) bool {
+	if err == context.DeadlineExceeded {
+		return true
+	}
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+func abortProxyError(c *gin.Context, target ProxyTarget, err error) {
+	code := http.StatusBadGateway
+	if isTimeoutError(err) {
+		code = http.StatusGatewayTimeout
+		err = ErrorProxyTimeout1.New(err, target.URL)
+	} else {
+		err = ErrorProxyFailed1.New(err, target.URL)
+	}
+	logger.Debugln(err)
+	c.JSON(code, gin.H{
+		"error": err.Error(),
+	})
+	c.Abort()
+}
+
+// copyResponseBody stream response body to client, flush after each write for server-sent events
+func copyResponseBody(dst gin.ResponseWriter, src io.Reader) error {
+	buffer := make([]byte, proxyBufferSize)
+	for {
+		n, err := src.Read(buffer)
+		if n > 0 {
+			if _, werr := dst.Write(buffer[:n]); werr != nil {
+				return werr
+			}
+			dst.Flush()
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (t *Mocker) proxyRoute(c *gin.Context) {
+	if t.config.Replay && t.serveFixture(c) {
+		return
+	}
+
+	proxyTarget := t.selectProxyTarget(c)
+	if proxyTarget == nil {
+		return
+	}
+
+	logger.Debugf("Proxy to: %s %s", c.Request.Method, proxyTarget.URL+c.Request.RequestURI)
+
+	if c.Request.Header.Get("Upgrade") == "websocket" {
+		if err := proxyWebSocket(c, proxyTarget.URL); err != nil {
+			logger.Debugln(err)
+			return
+		}
+		return
+	}
+
+	target, err := proxyTarget.targetURL()
+	if err != nil {
+		abortProxyError(c, *proxyTarget, err)
+		return
+	}
+
+	transport, err := t.proxyTransport(*proxyTarget)
+	if err != nil {
+		abortProxyError(c, *proxyTarget, err)
+		return
+	}
+
+	// buffer bodies only if exchange is recorded or checked
+	capture := t.config.Record || t.config.Contract
+	var reqBody []byte
+	var body io.Reader = c.Request.Body
+	if capture {
+		if reqBody, err = readRequestBody(c.Request); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		body = bytes.NewReader(reqBody)
+	}
+	if c.Request.ContentLength == 0 {
+		body = nil
+	}
+
+	req, err := proxyRequest(c, *proxyTarget, target, body)
+	if err != nil {
+		abortProxyError(c, *proxyTarget, err)
+		return
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		abortProxyError(c, *proxyTarget, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	removeHopHeaders(resp.Header)
+	outputHeader := c.Writer.Header()
+	// CORS policy of mock server takes place of proxy server's
+	corsApplied := outputHeader.Get(headerAccessControlAllowOrigin) != ""
+	for name, headers := range resp.Header {
+		if corsApplied && isCORSHeader(name) {
+			continue
+		}
+		for _, header := range headers {
+			outputHeader.Add(name, header)
+		}
+	}
+	if method, ok := t.currentResourceMatcher().resourceMethod(c.Request.URL.Path, c.Request.Method); ok {
+		exposeResponseHeaders(c, method)
+	}
+	c.Status(resp.StatusCode)
+	c.Writer.WriteHeaderNow()
+
+	var respBody bytes.Buffer
+	var src io.Reader = resp.Body
+	if capture {
+		src = io.TeeReader(resp.Body, &respBody)
+	}
+	if err = copyResponseBody(c.Writer, src); err != nil {
+		logger.Debugln(ErrorProxyFailed1.New(err, proxyTarget.URL))
+		c.Abort()
+		return
+	}
+
+	if t.config.Record {
+		errutil.Trace(t.recordFixture(c.Request, reqBody, resp, respBody.Bytes()))
+	}
+	if t.config.Contract {
+		t.reportContract(c, reqBody, resp, respBody.Bytes())
+	}
+}
@@ -2,6 +2,8 @@ package mocker
 
 import (
 	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/tsaikd/go-raml-parser/parser"
 )
@@ -19,8 +21,9 @@ func toGinResource(resource string) string {
 	return regRAMLParam.ReplaceAllString(resource, ":$1")
 }
 
-func checkConfigResource(resources parser.Resources) (err error) {
-	for respath := range config.Resources {
+// checkResources return error if any resource in config is not declared in RAML document
+func (t *Config) checkResources(resources parser.Resources) (err error) {
+	for respath := range t.Resources {
 		ramlpath := toRAMLResource(respath)
 		if _, exist := resources[ramlpath]; !exist {
 			return ErrorResourceNotFound1.New(nil, respath)
@@ -28,3 +31,96 @@ func checkConfigResource(resources parser.Resources) (err error) {
 	}
 	return nil
 }
+
+// resourceURIParameters return declared URI parameters of resource path,
+// parameter not declared in resource is inherited from parent resources, then from baseUriParameters
+func resourceURIParameters(rootdoc parser.RootDocument, ramlPath string) []*parser.Property {
+	params := []*parser.Property{}
+	for _, matches := range regRAMLParam.FindAllStringSubmatch(ramlPath, -1) {
+		if param := findURIParameter(rootdoc, ramlPath, matches[1]); param != nil {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+func findURIParameter(rootdoc parser.RootDocument, ramlPath string, name string) *parser.Property {
+	for ramlPath != "" {
+		if resource := rootdoc.Resources[ramlPath]; resource != nil {
+			if param := resource.URIParameters.Map()[name]; param != nil {
+				return param
+			}
+		}
+		idx := strings.LastIndex(ramlPath, "/")
+		if idx < 0 {
+			break
+		}
+		ramlPath = ramlPath[:idx]
+	}
+	return rootdoc.BaseURIParameters.Map()[name]
+}
+
+// resourcePattern is regular expression of RAML resource path
+type resourcePattern struct {
+	ramlPath string
+	params   []string
+	regexp   *regexp.Regexp
+}
+
+// resourceMatcher match request path to RAML resource, used for requests not bound to routes
+type resourceMatcher struct {
+	rootdoc  parser.RootDocument
+	patterns []resourcePattern
+}
+
+func newResourceMatcher(rootdoc parser.RootDocument) *resourceMatcher {
+	patterns := []resourcePattern{}
+	for ramlPath := range rootdoc.Resources {
+		expr := ""
+		last := 0
+		params := []string{}
+		for _, loc := range regRAMLParam.FindAllStringSubmatchIndex(ramlPath, -1) {
+			expr += regexp.QuoteMeta(ramlPath[last:loc[0]]) + `([^/]+)`
+			params = append(params, ramlPath[loc[2]:loc[3]])
+			last = loc[1]
+		}
+		expr += regexp.QuoteMeta(ramlPath[last:])
+		patterns = append(patterns, resourcePattern{
+			ramlPath: ramlPath,
+			params:   params,
+			regexp:   regexp.MustCompile("^" + expr + "/?$"),
+		})
+	}
+	// prefer resource with less URI parameters, e.g. /users/me before /users/{id}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i].params) != len(patterns[j].params) {
+			return len(patterns[i].params) < len(patterns[j].params)
+		}
+		return patterns[i].ramlPath < patterns[j].ramlPath
+	})
+	return &resourceMatcher{
+		rootdoc:  rootdoc,
+		patterns: patterns,
+	}
+}
+
+// match return RAML resource path and URI parameters of request path, return empty string if not matched
+func (t *resourceMatcher) match(path string) (ramlPath string, params map[string]string) {
+	for _, pattern := range t.patterns {
+		matches := pattern.regexp.FindStringSubmatch(path)
+		if matches == nil {
+			continue
+		}
+		params = map[string]string{}
+		for i, name := range pattern.params {
+			params[name] = matches[i+1]
+		}
+		return pattern.ramlPath, params
+	}
+	return "", nil
+}
+
+// currentResourceMatcher return matcher of RAML document currently bound
+func (t *Mocker) currentResourceMatcher() *resourceMatcher {
+	return t.currentBinding().matcher
+}
@@ -0,0 +1,138 @@
+package mocker
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/gin"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+// errors
+var (
+	ErrorInvalidStatusCode1  = errutil.NewFactory("invalid status code %q")
+	ErrorStatusCodeNotFound1 = errutil.NewFactory("status code %d not declared in RAML file")
+	ErrorNotAcceptable1      = errutil.NewFactory("no acceptable MIME type declared for %q")
+)
+
+// client can select response status code by header or query parameter
+const (
+	headerMockStatus = "X-Mock-Status"
+	queryMockStatus  = "__status"
+)
+
+// statusCodes return sorted status codes declared in responses
+func statusCodes(responses parser.Responses) []int {
+	codes := []int{}
+	for code := range responses {
+		codes = append(codes, int(code))
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+// defaultStatusCode return the lowest 2xx status code,
+// or the lowest status code if there is no 2xx status code
+func defaultStatusCode(codes []int) int {
+	for _, code := range codes {
+		if code >= 200 && code < 300 {
+			return code
+		}
+	}
+	return codes[0]
+}
+
+// requestedStatusCode return status code from request header or query parameter,
+// return 0 if client does not request any one
+func requestedStatusCode(c *gin.Context) (code int, err error) {
+	status := c.Request.Header.Get(headerMockStatus)
+	if status == "" {
+		status, _ = c.GetQuery(queryMockStatus)
+	}
+	if status == "" {
+		return 0, nil
+	}
+	if code, err = strconv.Atoi(status); err != nil {
+		return 0, ErrorInvalidStatusCode1.New(err, status)
+	}
+	return code, nil
+}
+
+// selectResponse return the response requested by client, fallback to route override,
+// response is nil if method does not declare any response body
+func selectResponse(c *gin.Context, responses parser.Responses) (code int, response *parser.Response, err error) {
+	if len(responses) < 1 {
+		return http.StatusOK, nil, nil
+	}
+
+	codes := statusCodes(responses)
+	if code, err = requestedStatusCode(c); err != nil {
+		return
+	}
+	if override, exist := contextOverride(c); code == 0 && exist {
+		code = override.Status
+	}
+	if code == 0 {
+		code = defaultStatusCode(codes)
+	}
+
+	response, exist := responses[parser.HTTPCode(code)]
+	if !exist {
+		return code, nil, ErrorStatusCodeNotFound1.New(nil, code)
+	}
+	return code, response, nil
+}
+
+// responseMIMETypes return MIME types of response sorted by server preference,
+// JSON first, then other types in order of name
+func responseMIMETypes(response *parser.Response) []string {
+	others := []string{}
+	for mimetype, body := range response.Bodies {
+		if body != nil && mimetype != mimeTypeJSON {
+			others = append(others, mimetype)
+		}
+	}
+	sort.Strings(others)
+
+	mimetypes := []string{}
+	if body, exist := response.Bodies[mimeTypeJSON]; exist && body != nil {
+		mimetypes = append(mimetypes, mimeTypeJSON)
+	}
+	return append(mimetypes, others...)
+}
+
+// negotiateResponseBody return the media type and body of response acceptable by client
+func negotiateResponseBody(c *gin.Context, response *parser.Response) (mimetype string, body parser.Body, err error) {
+	if response == nil || len(response.Bodies) < 1 {
+		return mimeTypeJSON, parser.Body{}, nil
+	}
+
+	accept := c.Request.Header.Get("Accept")
+	mimetype, ok := negotiateMIMEType(accept, responseMIMETypes(response))
+	if !ok {
+		return "", parser.Body{}, ErrorNotAcceptable1.New(nil, accept)
+	}
+	return mimetype, *response.Bodies[mimetype], nil
+}
+
+func abortStatusCodeNotFound(c *gin.Context, responses parser.Responses, err error) {
+	code := http.StatusNotFound
+	if ErrorInvalidStatusCode1.Match(err) {
+		code = http.StatusBadRequest
+	}
+	c.JSON(code, gin.H{
+		"error":  err.Error(),
+		"status": statusCodes(responses),
+	})
+	c.Abort()
+}
+
+func abortNotAcceptable(c *gin.Context, response *parser.Response, err error) {
+	c.JSON(http.StatusNotAcceptable, gin.H{
+		"error":     err.Error(),
+		"mimetypes": responseMIMETypes(response),
+	})
+	c.Abort()
+}
@@ -0,0 +1,513 @@
+package mocker
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/gin"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+// errors
+var (
+	ErrorStateIDConflict1 = errutil.NewFactory("record id %q already exists")
+)
+
+// default id property name of stateful records
+const stateDefaultIDName = "id"
+
+var regItemResource = regexp.MustCompile(`^(.*)/{(\w+)}$`)
+
+// stateRecord is one record of stateful collection
+type stateRecord map[string]interface{}
+
+// stateResource is a collection or item resource recognized from resource tree for stateful mode
+type stateResource struct {
+	// RAML path of collection resource, e.g. /organisation
+	collection string
+	// URI parameter name of item resource, e.g. id for /organisation/{id}
+	idParam string
+	// true if resource is the item resource of collection
+	item bool
+	// true if collection GET response is declared as a single object,
+	// then the latest record is returned instead of all records
+	single bool
+}
+
+// stateKey identify collection of request, path is collection RAML path with URI parameters of request
+type stateKey struct {
+	template string
+	path     string
+}
+
+// key return collection key of request, e.g. /users/1/posts for /users/{id}/posts/{postId}
+func (t *stateResource) key(c *gin.Context) stateKey {
+	return stateKey{
+		template: t.collection,
+		path: regRAMLParam.ReplaceAllStringFunc(t.collection, func(param string) string {
+			return c.Param(strings.Trim(param, "{}"))
+		}),
+	}
+}
+
+// stateResources recognize collection and item resources in resource tree,
+// a collection resource has an item resource with URI parameter or has both GET and POST methods
+func stateResources(rootdoc parser.RootDocument) map[string]*stateResource {
+	result := map[string]*stateResource{}
+	for ramlPath := range rootdoc.Resources {
+		matches := regItemResource.FindStringSubmatch(ramlPath)
+		if matches == nil || matches[1] == "" {
+			continue
+		}
+		result[ramlPath] = &stateResource{
+			collection: matches[1],
+			idParam:    matches[2],
+			item:       true,
+		}
+		if _, exist := rootdoc.Resources[matches[1]]; exist {
+			result[matches[1]] = &stateResource{
+				collection: matches[1],
+				idParam:    matches[2],
+			}
+		}
+	}
+
+	for ramlPath, resource := range rootdoc.Resources {
+		if _, exist := result[ramlPath]; exist || resource == nil {
+			continue
+		}
+		if _, exist := resource.Methods["get"]; !exist {
+			continue
+		}
+		if _, exist := resource.Methods["post"]; !exist {
+			continue
+		}
+		result[ramlPath] = &stateResource{
+			collection: ramlPath,
+			idParam:    stateDefaultIDName,
+		}
+	}
+
+	for _, res := range result {
+		if res.item {
+			continue
+		}
+		resource := rootdoc.Resources[res.collection]
+		if resource == nil {
+			continue
+		}
+		if body := stateResponseBody(resource.Methods["get"]); body != nil {
+			res.single = isObjectType(rootdoc.Types, body.APIType)
+		}
+	}
+	return result
+}
+
+// stateCollection store records of one collection resource in declaration order
+type stateCollection struct {
+	// RAML path of collection resource, e.g. /users/{id}/posts
+	template string
+	idName   string
+	nextID   int64
+	stringID bool
+	records  []stateRecord
+	seeds    []stateRecord
+}
+
+func (t *stateCollection) reset() {
+	t.records = []stateRecord{}
+	t.nextID = 1
+	t.stringID = false
+	for _, seed := range t.seeds {
+		if _, err := t.insert(copyStateRecord(seed)); err != nil {
+			errutil.Trace(err)
+		}
+	}
+}
+
+func (t *stateCollection) index(id string) int {
+	for i, record := range t.records {
+		if formatText(record[t.idName]) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// insert record into collection, assign new id if record id is empty,
+// return error without inserting if record id already exists
+func (t *stateCollection) insert(record stateRecord) (stateRecord, error) {
+	if id := record[t.idName]; id != nil && t.index(formatText(id)) >= 0 {
+		return nil, ErrorStateIDConflict1.New(nil, formatText(id))
+	}
+	switch id := record[t.idName].(type) {
+	case nil:
+		if t.stringID {
+			record[t.idName] = strconv.FormatInt(t.nextID, 10)
+		} else {
+			record[t.idName] = t.nextID
+		}
+		t.nextID++
+	case float64:
+		if int64(id) >= t.nextID {
+			t.nextID = int64(id) + 1
+		}
+	case int64:
+		if id >= t.nextID {
+			t.nextID = id + 1
+		}
+	case string:
+		t.stringID = true
+		if num, err := strconv.ParseInt(id, 10, 64); err == nil && num >= t.nextID {
+			t.nextID = num + 1
+		}
+	}
+	t.records = append(t.records, record)
+	return record, nil
+}
+
+// stateStore is in-memory store of all stateful collections,
+// collections of nested resource are keyed by resource path with URI parameters, e.g. /users/1/posts,
+// and created from collection template of RAML path on first access, e.g. /users/{id}/posts
+type stateStore struct {
+	mutex       sync.Mutex
+	templates   map[string]*stateCollection
+	collections map[string]*stateCollection
+}
+
+func newStateStore() *stateStore {
+	return &stateStore{
+		templates:   map[string]*stateCollection{},
+		collections: map[string]*stateCollection{},
+	}
+}
+
+// seed replace collection templates with records in RAML examples,
+// records of collections still declared are kept, so reloading RAML file does not wipe them
+func (t *stateStore) seed(rootdoc parser.RootDocument, resources map[string]*stateResource) {
+	templates := map[string]*stateCollection{}
+	for _, res := range resources {
+		if _, exist := templates[res.collection]; exist {
+			continue
+		}
+		templates[res.collection] = &stateCollection{
+			idName: stateIDName(rootdoc, res),
+			seeds:  stateSeeds(rootdoc, res),
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.templates = templates
+	for path, collection := range t.collections {
+		if _, exist := templates[collection.template]; !exist {
+			delete(t.collections, path)
+		}
+	}
+}
+
+// reset all collections to seed records
+func (t *stateStore) reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.collections = map[string]*stateCollection{}
+}
+
+// collection return collection of key, create it from template if not exist, caller should hold mutex
+func (t *stateStore) collection(key stateKey) *stateCollection {
+	if coll, exist := t.collections[key.path]; exist {
+		return coll
+	}
+	coll := &stateCollection{idName: stateDefaultIDName, template: key.template}
+	if template, exist := t.templates[key.template]; exist {
+		coll.idName = template.idName
+		coll.seeds = template.seeds
+	}
+	coll.reset()
+	t.collections[key.path] = coll
+	return coll
+}
+
+func (t *stateStore) list(key stateKey) []stateRecord {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	records := []stateRecord{}
+	for _, record := range t.collection(key).records {
+		records = append(records, copyStateRecord(record))
+	}
+	return records
+}
+
+func (t *stateStore) get(key stateKey, id string) (stateRecord, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	coll := t.collection(key)
+	if idx := coll.index(id); idx >= 0 {
+		return copyStateRecord(coll.records[idx]), true
+	}
+	return nil, false
+}
+
+// create insert record into collection, return error if record id already exists
+func (t *stateStore) create(key stateKey, record stateRecord) (stateRecord, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	result, err := t.collection(key).insert(copyStateRecord(record))
+	if err != nil {
+		return nil, err
+	}
+	return copyStateRecord(result), nil
+}
+
+// update record by id, replace whole record if merge is false
+func (t *stateStore) update(key stateKey, id string, record stateRecord, merge bool) (stateRecord, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	coll := t.collection(key)
+	idx := coll.index(id)
+	if idx < 0 {
+		return nil, false
+	}
+
+	result := copyStateRecord(record)
+	if merge {
+		result = copyStateRecord(coll.records[idx])
+		for key, value := range record {
+			result[key] = copyStateValue(value)
+		}
+	}
+	result[coll.idName] = coll.records[idx][coll.idName]
+	coll.records[idx] = result
+	return copyStateRecord(result), true
+}
+
+func (t *stateStore) remove(key stateKey, id string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	coll := t.collection(key)
+	idx := coll.index(id)
+	if idx < 0 {
+		return false
+	}
+	coll.records = append(coll.records[:idx], coll.records[idx+1:]...)
+	return true
+}
+
+// stateIDName return id property name of collection,
+// use the URI parameter name if it is declared as property of item type
+func stateIDName(rootdoc parser.RootDocument, res *stateResource) string {
+	for _, apiType := range stateTypes(rootdoc, res) {
+		for _, property := range objectProperties(rootdoc.Types, apiType) {
+			if property.Name == res.idParam {
+				return res.idParam
+			}
+		}
+	}
+	return stateDefaultIDName
+}
+
+// stateTypes return declared types of item records in collection
+func stateTypes(rootdoc parser.RootDocument, res *stateResource) []parser.APIType {
+	types := []parser.APIType{}
+	if collection := rootdoc.Resources[res.collection]; collection != nil {
+		if method := collection.Methods["post"]; method != nil {
+			for _, body := range method.Bodies {
+				if body != nil {
+					types = append(types, body.APIType)
+				}
+			}
+		}
+	}
+	if item := rootdoc.Resources[res.collection+"/{"+res.idParam+"}"]; item != nil {
+		if body := stateResponseBody(item.Methods["get"]); body != nil {
+			types = append(types, body.APIType)
+		}
+	}
+	return types
+}
+
+// stateSeeds return records in examples of collection and item GET responses
+func stateSeeds(rootdoc parser.RootDocument, res *stateResource) []stateRecord {
+	seeds := []stateRecord{}
+	if collection := rootdoc.Resources[res.collection]; collection != nil {
+		if body := stateResponseBody(collection.Methods["get"]); body != nil {
+			for _, example := range stateExampleValues(*body) {
+				switch value := example.(type) {
+				case []interface{}:
+					for _, item := range value {
+						if record, ok := item.(map[string]interface{}); ok {
+							seeds = append(seeds, stateRecord(record))
+						}
+					}
+				case map[string]interface{}:
+					seeds = append(seeds, stateRecord(value))
+				}
+			}
+		}
+	}
+	if len(seeds) > 0 {
+		return seeds
+	}
+
+	if item := rootdoc.Resources[res.collection+"/{"+res.idParam+"}"]; item != nil {
+		if body := stateResponseBody(item.Methods["get"]); body != nil {
+			for _, example := range stateExampleValues(*body) {
+				if record, ok := example.(map[string]interface{}); ok {
+					seeds = append(seeds, stateRecord(record))
+				}
+			}
+		}
+	}
+	return seeds
+}
+
+// stateResponseBody return JSON body of the default response of method
+func stateResponseBody(method *parser.Method) *parser.Body {
+	if method == nil || len(method.Responses) < 1 {
+		return nil
+	}
+	response := method.Responses[parser.HTTPCode(defaultStatusCode(statusCodes(method.Responses)))]
+	if response == nil {
+		return nil
+	}
+	for mimetype, body := range response.Bodies {
+		if body != nil && isJSONMIMEType(mimetype) {
+			return body
+		}
+	}
+	return nil
+}
+
+// stateExampleValues return all example values of body in order of name
+func stateExampleValues(body parser.Body) []interface{} {
+	values := []interface{}{}
+	if body.Example.Value.Type != "" {
+		values = append(values, valueToInterface(body.Example.Value))
+	}
+	for _, name := range exampleNames(body) {
+		if example := body.Examples[name]; example != nil {
+			values = append(values, valueToInterface(example.Value))
+		}
+	}
+	return values
+}
+
+// copyStateRecord return deep copy of record, records never share values with request or response
+func copyStateRecord(record stateRecord) stateRecord {
+	result := stateRecord{}
+	for key, value := range record {
+		result[key] = copyStateValue(value)
+	}
+	return result
+}
+
+// copyStateValue return deep copy of generic JSON value
+func copyStateValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+		for key, item := range value {
+			result[key] = copyStateValue(item)
+		}
+		return result
+	case stateRecord:
+		return copyStateRecord(value)
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			result[i] = copyStateValue(item)
+		}
+		return result
+	}
+	return value
+}
+
+// isStateful return true if request should be served by stateful store,
+// client can still request static example by selecting status code or example name
+func (t *Mocker) isStateful(c *gin.Context, res *stateResource) bool {
+	if !t.config.Stateful || res == nil {
+		return false
+	}
+	if code, _ := requestedStatusCode(c); code != 0 {
+		return false
+	}
+	return requestedExampleName(c) == ""
+}
+
+// serveState serve request by stateful store
+func (t *Mocker) serveState(c *gin.Context, res *stateResource, method parser.Method, requestBody parser.Value) {
+	store := t.currentBinding().store
+	key := res.key(c)
+	record := stateRecord{}
+	if body, ok := valueToInterface(requestBody).(map[string]interface{}); ok {
+		record = stateRecord(body)
+	}
+
+	methodName := strings.ToUpper(c.Request.Method)
+	if !res.item {
+		switch methodName {
+		case http.MethodGet:
+			records := store.list(key)
+			if !res.single {
+				outputState(c, stateStatusCode(method, http.StatusOK), records)
+				return
+			}
+			if len(records) < 1 {
+				c.AbortWithStatus(http.StatusNotFound)
+				return
+			}
+			outputState(c, stateStatusCode(method, http.StatusOK), records[len(records)-1])
+		case http.MethodPost:
+			result, err := store.create(key, record)
+			if err != nil {
+				c.AbortWithError(http.StatusConflict, err)
+				return
+			}
+			outputState(c, stateStatusCode(method, http.StatusCreated), result)
+		default:
+			c.AbortWithStatus(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id := c.Param(res.idParam)
+	switch methodName {
+	case http.MethodGet:
+		if result, exist := store.get(key, id); exist {
+			outputState(c, stateStatusCode(method, http.StatusOK), result)
+			return
+		}
+	case http.MethodPut, http.MethodPatch:
+		if result, exist := store.update(key, id, record, methodName == http.MethodPatch); exist {
+			outputState(c, stateStatusCode(method, http.StatusOK), result)
+			return
+		}
+	case http.MethodDelete:
+		if store.remove(key, id) {
+			c.Status(stateStatusCode(method, http.StatusNoContent))
+			return
+		}
+	default:
+		c.AbortWithStatus(http.StatusMethodNotAllowed)
+		return
+	}
+	c.AbortWithStatus(http.StatusNotFound)
+}
+
+// stateStatusCode return the lowest 2xx status code declared in method, or the fallback code
+func stateStatusCode(method parser.Method, fallback int) int {
+	for _, code := range statusCodes(method.Responses) {
+		if code >= 200 && code < 300 {
+			return code
+		}
+	}
+	return fallback
+}
+
+func outputState(c *gin.Context, code int, data interface{}) {
+	outputJSON(c, code, mimeTypeJSON, parser.APIType{}, nil, data)
+}
@@ -0,0 +1,204 @@
+package mocker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/gin"
+	"github.com/tsaikd/go-raml-parser/parser"
+	"gopkg.in/yaml.v2"
+)
+
+// errors
+var (
+	ErrorProxyTargetInvalid1   = errutil.NewFactory("invalid proxy target %q, expected format: prefix=url")
+	ErrorProxyTargetURLEmpty1  = errutil.NewFactory("proxy target %q URL is empty")
+	ErrorReadProxyTargetsFile1 = errutil.NewFactory("read proxy targets file %q failed")
+	ErrorProxyTargetTLS1       = errutil.NewFactory("load TLS options of proxy target %q failed")
+)
+
+// ProxyTarget is an upstream server of proxy, requests are routed to the target
+// by RAML resource, RAML annotation or path prefix
+type ProxyTarget struct {
+	Name       string            `yaml:"name" json:"name,omitempty"`
+	URL        string            `yaml:"url" json:"url"`
+	PathPrefix string            `yaml:"prefix" json:"prefix,omitempty"`
+	Resources  []string          `yaml:"resources" json:"resources,omitempty"`
+	Annotation string            `yaml:"annotation" json:"annotation,omitempty"`
+	Headers    map[string]string `yaml:"headers" json:"headers,omitempty"`
+	TLS        ProxyTargetTLS    `yaml:"tls" json:"tls,omitempty"`
+}
+
+// ProxyTargetTLS is TLS options of connection to proxy target
+type ProxyTargetTLS struct {
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify" json:"insecureSkipVerify,omitempty"`
+	ServerName         string `yaml:"serverName" json:"serverName,omitempty"`
+	CAFile             string `yaml:"caFile" json:"caFile,omitempty"`
+	CertFile           string `yaml:"certFile" json:"certFile,omitempty"`
+	KeyFile            string `yaml:"keyFile" json:"keyFile,omitempty"`
+}
+
+func (t ProxyTarget) name() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.URL
+}
+
+// ParseProxyTarget parse proxy target from command line, e.g. /users=http://users.backend:8080
+func ParseProxyTarget(text string) (target ProxyTarget, err error) {
+	parts := strings.SplitN(text, "=", 2)
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "/") || parts[1] == "" {
+		return target, ErrorProxyTargetInvalid1.New(nil, text)
+	}
+	return ProxyTarget{
+		URL:        parts[1],
+		PathPrefix: parts[0],
+	}, nil
+}
+
+// proxyTargetsFile is the content of proxy targets file in YAML or JSON
+type proxyTargetsFile struct {
+	Targets []ProxyTarget `yaml:"targets" json:"targets"`
+}
+
+// LoadProxyTargets load proxy targets from YAML or JSON file
+func LoadProxyTargets(path string) ([]ProxyTarget, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, ErrorReadProxyTargetsFile1.New(err, path)
+	}
+	file := proxyTargetsFile{}
+	if err = yaml.Unmarshal(raw, &file); err != nil {
+		return nil, ErrorReadProxyTargetsFile1.New(err, path)
+	}
+	for _, target := range file.Targets {
+		if target.URL == "" {
+			return nil, ErrorProxyTargetURLEmpty1.New(nil, target.name())
+		}
+	}
+	return file.Targets, nil
+}
+
+// hasProxyTarget return true if any proxy target configured
+func (t *Config) hasProxyTarget() bool {
+	return t.Proxy != "" || len(t.ProxyTargets) > 0
+}
+
+// selectProxyTarget return proxy target of request, the order of routing rules is
+// RAML resource, RAML annotation, the longest path prefix, then default proxy server,
+// return nil if no proxy target matched
+func (t *Mocker) selectProxyTarget(c *gin.Context) *ProxyTarget {
+	if len(t.config.ProxyTargets) > 0 {
+		matcher := t.currentResourceMatcher()
+		ramlPath, _ := matcher.match(c.Request.URL.Path)
+
+		if ramlPath != "" {
+			for i, target := range t.config.ProxyTargets {
+				for _, resource := range target.Resources {
+					if toRAMLResource(resource) == ramlPath {
+						return &t.config.ProxyTargets[i]
+					}
+				}
+			}
+
+			if resource := matcher.rootdoc.Resources[ramlPath]; resource != nil {
+				annotations := []map[string]bool{annotationNames(resource.Annotations)}
+				if method := resource.Methods[strings.ToLower(c.Request.Method)]; method != nil {
+					annotations = append([]map[string]bool{annotationNames(method.Annotations)}, annotations...)
+				}
+				for _, names := range annotations {
+					for i, target := range t.config.ProxyTargets {
+						if target.Annotation != "" && names[strings.Trim(target.Annotation, "()")] {
+							return &t.config.ProxyTargets[i]
+						}
+					}
+				}
+			}
+		}
+
+		var result *ProxyTarget
+		for i, target := range t.config.ProxyTargets {
+			if target.PathPrefix == "" || !hasPathPrefix(c.Request.URL.Path, target.PathPrefix) {
+				continue
+			}
+			if result == nil || len(target.PathPrefix) > len(result.PathPrefix) {
+				result = &t.config.ProxyTargets[i]
+			}
+		}
+		if result != nil {
+			return result
+		}
+	}
+
+	if t.config.Proxy != "" {
+		return &ProxyTarget{URL: t.config.Proxy}
+	}
+	return nil
+}
+
+// annotationNames return annotation names without parentheses
+func annotationNames(annotations parser.Annotations) map[string]bool {
+	names := map[string]bool{}
+	for name := range annotations {
+		names[strings.Trim(name, "()")] = true
+	}
+	return names
+}
+
+// hasPathPrefix return true if path is prefix or under prefix directory
+func hasPathPrefix(path string, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// tlsConfig return TLS config of proxy target, return nil if no TLS option configured
+func (t ProxyTarget) tlsConfig() (*tls.Config, error) {
+	if t.TLS == (ProxyTargetTLS{}) {
+		return nil, nil
+	}
+	result := &tls.Config{
+		InsecureSkipVerify: t.TLS.InsecureSkipVerify,
+		ServerName:         t.TLS.ServerName,
+	}
+	if t.TLS.CAFile != "" {
+		raw, err := ioutil.ReadFile(t.TLS.CAFile)
+		if err != nil {
+			return nil, ErrorProxyTargetTLS1.New(err, t.name())
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(raw) {
+			return nil, ErrorProxyTargetTLS1.New(nil, t.name())
+		}
+		result.RootCAs = pool
+	}
+	if t.TLS.CertFile != "" || t.TLS.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.TLS.CertFile, t.TLS.KeyFile)
+		if err != nil {
+			return nil, ErrorProxyTargetTLS1.New(err, t.name())
+		}
+		result.Certificates = []tls.Certificate{cert}
+	}
+	return result, nil
+}
+
+// targetURL return parsed URL of proxy target
+func (t ProxyTarget) targetURL() (*url.URL, error) {
+	target, err := url.Parse(t.URL)
+	if err != nil || target.Host == "" {
+		return nil, ErrorProxyInvalidURL1.New(err, t.URL)
+	}
+	return target, nil
+}
+
+// injectHeaders set configured headers of proxy target into request
+func (t ProxyTarget) injectHeaders(header http.Header) {
+	for name, value := range t.Headers {
+		header.Set(name, value)
+	}
+}
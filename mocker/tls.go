@@ -0,0 +1,110 @@
+package mocker
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+)
+
+// errors
+var (
+	ErrorTLSKeyPairRequired = errutil.NewFactory("both TLS certificate and key files are required")
+	ErrorTLSLoadKeyPair2    = errutil.NewFactory("load TLS certificate %q and key %q failed")
+	ErrorTLSSelfSigned      = errutil.NewFactory("generate self-signed TLS certificate failed")
+	ErrorTLSPortConflict1   = errutil.NewFactory("mock servers on port %d have different TLS settings")
+)
+
+// hosts of generated self-signed certificate
+var selfSignedHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+const selfSignedValidFor = 365 * 24 * time.Hour
+
+// isTLS return true if mock server should listen on HTTPS
+func (t *Config) isTLS() bool {
+	return t.TLSCert != "" || t.TLSKey != "" || t.TLSSelfSigned
+}
+
+// isSameTLS return true if TLS settings of configs are the same, used by mock servers sharing one port
+func (t *Config) isSameTLS(other *Config) bool {
+	return t.TLSCert == other.TLSCert &&
+		t.TLSKey == other.TLSKey &&
+		t.TLSSelfSigned == other.TLSSelfSigned
+}
+
+// tlsConfig return TLS config of mock server with HTTP/2 enabled, nil if HTTPS is disabled
+func (t *Config) tlsConfig() (*tls.Config, error) {
+	if !t.isTLS() {
+		return nil, nil
+	}
+
+	var cert tls.Certificate
+	var err error
+	switch {
+	case t.TLSCert != "" || t.TLSKey != "":
+		if t.TLSCert == "" || t.TLSKey == "" {
+			return nil, ErrorTLSKeyPairRequired.New(nil)
+		}
+		if cert, err = tls.LoadX509KeyPair(t.TLSCert, t.TLSKey); err != nil {
+			return nil, ErrorTLSLoadKeyPair2.New(err, t.TLSCert, t.TLSKey)
+		}
+	default:
+		if cert, err = selfSignedCertificate(selfSignedHosts); err != nil {
+			return nil, ErrorTLSSelfSigned.New(err)
+		}
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}, nil
+}
+
+// selfSignedCertificate generate in-memory certificate for hosts, never written to disk
+func selfSignedCertificate(hosts []string) (cert tls.Certificate, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return
+	}
+
+	notBefore := time.Now().Add(-time.Hour)
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"go-raml-mocker"}},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(selfSignedValidFor),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, nil
+}
@@ -0,0 +1,235 @@
+package mocker
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+// errors
+var (
+	ErrorTypeNotFound1 = errutil.NewFactory("type %q not found in RAML file")
+)
+
+// RAML built-in types not checked by value type
+const (
+	typeAny          = "any"
+	typeNil          = "nil"
+	typeFile         = "file"
+	typeDateOnly     = "date-only"
+	typeTimeOnly     = "time-only"
+	typeDateTimeOnly = "datetime-only"
+	typeDateTime     = "datetime"
+)
+
+// max inheritance depth of types, used to prevent recursive type declaration
+const typeMaxInheritDepth = 8
+
+// isObjectType return true if base type of apiType is object
+func isObjectType(types parser.APITypes, apiType parser.APIType) bool {
+	return baseTypeOf(types, apiType) == parser.TypeObject
+}
+
+// baseTypeOf return the built-in base type of apiType, return empty string if unknown
+func baseTypeOf(types parser.APITypes, apiType parser.APIType) string {
+	for i := 0; i < typeMaxInheritDepth; i++ {
+		if len(apiType.Properties.Slice()) > 0 {
+			return parser.TypeObject
+		}
+		expr := typeExpression(apiType)
+		switch {
+		case len(splitUnionTypeExpression(expr)) > 1:
+			return ""
+		case strings.HasSuffix(expr, "[]"):
+			return parser.TypeArray
+		case expr == "":
+			if apiType.Items != nil {
+				return parser.TypeArray
+			}
+			return ""
+		case isBuiltinType(expr):
+			return expr
+		}
+		parent, exist := types[expr]
+		if !exist || parent == nil {
+			return ""
+		}
+		apiType = *parent
+	}
+	return ""
+}
+
+// arrayItemType return item type of array type
+func arrayItemType(types parser.APITypes, apiType parser.APIType) parser.APIType {
+	for i := 0; i < typeMaxInheritDepth; i++ {
+		if apiType.Items != nil {
+			return *apiType.Items
+		}
+		expr := typeExpression(apiType)
+		if strings.HasSuffix(expr, "[]") {
+			item := parser.APIType{}
+			item.Type = trimTypeExpression(strings.TrimSuffix(expr, "[]"))
+			return item
+		}
+		parent, exist := types[expr]
+		if !exist || parent == nil {
+			break
+		}
+		apiType = *parent
+	}
+	return parser.APIType{}
+}
+
+// objectProperties return properties of apiType including inherited properties
+func objectProperties(types parser.APITypes, apiType parser.APIType) []*parser.Property {
+	properties := []*parser.Property{}
+	names := map[string]bool{}
+	for i := 0; i < typeMaxInheritDepth; i++ {
+		for _, property := range apiType.Properties.Slice() {
+			if property == nil || names[property.Name] {
+				continue
+			}
+			names[property.Name] = true
+			properties = append(properties, property)
+		}
+		parent, exist := types[typeExpression(apiType)]
+		if !exist || parent == nil {
+			break
+		}
+		apiType = *parent
+	}
+	return properties
+}
+
+func isBuiltinType(expr string) bool {
+	switch expr {
+	case parser.TypeObject, parser.TypeArray, parser.TypeString, parser.TypeInteger,
+		parser.TypeNumber, parser.TypeBoolean, typeAny, typeNil, typeFile,
+		typeDateOnly, typeTimeOnly, typeDateTimeOnly, typeDateTime:
+		return true
+	}
+	return false
+}
+
+// inheritAPIType return child type with facets not declared filled from parent type
+func inheritAPIType(child parser.APIType, parent parser.APIType) parser.APIType {
+	result := child
+	result.Type = parent.Type
+	if result.Example.Value.Type == "" && result.Examples.IsEmpty() {
+		result.Example = parent.Example
+		result.Examples = parent.Examples
+	}
+	if len(result.Enum.Array) < 1 {
+		result.Enum = parent.Enum
+	}
+	if result.Pattern == "" {
+		result.Pattern = parent.Pattern
+	}
+	if result.MinLength == 0 {
+		result.MinLength = parent.MinLength
+	}
+	if result.MaxLength == 0 {
+		result.MaxLength = parent.MaxLength
+	}
+	if result.Minimum == nil {
+		result.Minimum = parent.Minimum
+	}
+	if result.Maximum == nil {
+		result.Maximum = parent.Maximum
+	}
+	if result.MultipleOf == 0 {
+		result.MultipleOf = parent.MultipleOf
+	}
+	if result.Format == "" {
+		result.Format = parent.Format
+	}
+	if result.Items == nil {
+		result.Items = parent.Items
+	}
+	if result.MinItems == 0 {
+		result.MinItems = parent.MinItems
+	}
+	if result.MaxItems == 0 {
+		result.MaxItems = parent.MaxItems
+	}
+	return result
+}
+
+// typeExpression return type expression of apiType
+func typeExpression(apiType parser.APIType) string {
+	return trimTypeExpression(apiType.Type)
+}
+
+// trimTypeExpression trim spaces and parentheses enclosing the whole expression,
+// e.g. "(A | B)" to "A | B", but "(A | B) | (C | D)" is kept
+func trimTypeExpression(expr string) string {
+	expr = strings.TrimSpace(expr)
+	for isEnclosedTypeExpression(expr) {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	return expr
+}
+
+// isEnclosedTypeExpression return true if the first parenthesis of expr is closed by the last character
+func isEnclosedTypeExpression(expr string) bool {
+	if !strings.HasPrefix(expr, "(") || !strings.HasSuffix(expr, ")") {
+		return false
+	}
+	level := 0
+	for i, c := range expr {
+		switch c {
+		case '(':
+			level++
+		case ')':
+			level--
+			if level == 0 {
+				return i == len(expr)-1
+			}
+		}
+	}
+	return false
+}
+
+// splitUnionTypeExpression return member types of union type expression, e.g. "Cat | Dog"
+func splitUnionTypeExpression(expr string) []string {
+	members := []string{}
+	level := 0
+	start := 0
+	for i, c := range expr {
+		switch c {
+		case '(':
+			level++
+		case ')':
+			level--
+		case '|':
+			if level == 0 {
+				members = append(members, trimTypeExpression(expr[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(members, trimTypeExpression(expr[start:]))
+}
+
+// scalarValue convert text to scalar value of RAML type, e.g. XML text or request parameter,
+// keep text value if conversion failed, then type checking will report the error
+func scalarValue(types parser.APITypes, apiType parser.APIType, text string) interface{} {
+	text = strings.TrimSpace(text)
+	switch baseTypeOf(types, apiType) {
+	case parser.TypeInteger:
+		if value, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return value
+		}
+	case parser.TypeNumber:
+		if value, err := strconv.ParseFloat(text, 64); err == nil {
+			return value
+		}
+	case parser.TypeBoolean:
+		if value, err := strconv.ParseBool(text); err == nil {
+			return value
+		}
+	}
+	return text
+}
@@ -0,0 +1,21 @@
+package mocker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_trimTypeExpression(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	require.Equal("Cat", trimTypeExpression(" Cat "))
+	require.Equal("Cat | Dog", trimTypeExpression("(Cat | Dog)"))
+	require.Equal("Cat | Dog", trimTypeExpression("((Cat | Dog))"))
+	require.Equal("(Cat | Dog)[]", trimTypeExpression("(Cat | Dog)[]"))
+	require.Equal("(A | B) | (C | D)", trimTypeExpression("(A | B) | (C | D)"))
+	require.Equal("(A | B) | (C | D)", trimTypeExpression("((A | B) | (C | D))"))
+
+	require.Equal([]string{"A | B", "C | D"}, splitUnionTypeExpression(trimTypeExpression("(A | B) | (C | D)")))
+}
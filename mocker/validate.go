@@ -0,0 +1,449 @@
+package mocker
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/gin"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+// errors
+var (
+	ErrorValidationFailed = errutil.NewFactory("request validation failed")
+)
+
+// locations of violation in request or response
+const (
+	locationHeader         = "header"
+	locationQuery          = "query"
+	locationURI            = "uri"
+	locationBody           = "body"
+	locationStatus         = "status"
+	locationResponseHeader = "response-header"
+	locationResponseBody   = "response-body"
+)
+
+const mimeTypeProblemJSON = "application/problem+json"
+
+// context key of request violations, set by route handler
+const contextKeyViolations = "mocker.violations"
+
+// max depth of nested value to validate, used to prevent recursive type declaration
+const validateMaxDepth = 32
+
+// violation is a request value not satisfied RAML declaration
+type violation struct {
+	Location string      `json:"location"`
+	Pointer  string      `json:"pointer"`
+	Expected string      `json:"expected"`
+	Actual   interface{} `json:"actual,omitempty"`
+	Message  string      `json:"message"`
+}
+
+func (t violation) Error() string {
+	return fmt.Sprintf("%s %s: %s", t.Location, t.Pointer, t.Message)
+}
+
+// validator collect all violations of request in one pass
+type validator struct {
+	types      parser.APITypes
+	config     *Config
+	violations []violation
+}
+
+func newValidator(types parser.APITypes, conf *Config) *validator {
+	return &validator{
+		types:      types,
+		config:     conf,
+		violations: []violation{},
+	}
+}
+
+func (v *validator) add(location string, pointer string, expected string, actual interface{}, message string) {
+	v.violations = append(v.violations, violation{
+		Location: location,
+		Pointer:  pointer,
+		Expected: expected,
+		Actual:   actual,
+		Message:  message,
+	})
+}
+
+// pointerEscape escape JSON pointer reference token, RFC 6901
+func pointerEscape(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+// validateHeader check request or response headers with declared header
+func (v *validator) validateHeader(location string, headers http.Header, header parser.Property) {
+	pointer := "/" + pointerEscape(header.Name)
+	values, exist := headers[http.CanonicalHeaderKey(header.Name)]
+	if !exist || len(values) < 1 || values[0] == "" {
+		if header.Required {
+			v.add(location, pointer, "required", nil, ErrorHeaderRequired1.New(nil, header.Name).Error())
+		}
+		return
+	}
+	v.validateText(location, pointer, header.APIType, values[0])
+}
+
+// validateText check parameter value in text, the text is converted to declared type before checking
+func (v *validator) validateText(location string, pointer string, apiType parser.APIType, text string) {
+	v.validateValue(location, pointer, apiType, scalarValue(v.types, apiType, text))
+}
+
+// validateQueryParameter check query parameter, parameter can be in query string, form or request body
+func (v *validator) validateQueryParameter(c *gin.Context, qp parser.Property, requestBody parser.Value) {
+	pointer := "/" + pointerEscape(qp.Name)
+	param := getParam(c, qp.Name, requestBody)
+	if param.IsEmpty() {
+		if qp.Required {
+			v.add(locationQuery, pointer, "required", nil, ErrorQueryParameterRequired1.New(nil, qp.Name).Error())
+		}
+		return
+	}
+	if param.Type == parser.TypeString {
+		v.validateText(locationQuery, pointer, qp.APIType, param.String)
+		return
+	}
+	v.validateValue(locationQuery, pointer, qp.APIType, param)
+}
+
+// validateTrait check headers and query parameters of trait and inherited traits
+func (v *validator) validateTrait(c *gin.Context, trait parser.Trait, requestBody parser.Value) {
+	for _, header := range trait.Headers.Slice() {
+		v.validateHeader(locationHeader, c.Request.Header, *header)
+	}
+	for _, qp := range trait.QueryParameters.Slice() {
+		v.validateQueryParameter(c, *qp, requestBody)
+	}
+	for _, inherit := range trait.Is {
+		v.validateTrait(c, *inherit, requestBody)
+	}
+}
+
+// validateURIParameter check URI parameter of matched route
+func (v *validator) validateURIParameter(c *gin.Context, param parser.Property) {
+	text, _ := c.Params.Get(param.Name)
+	v.validateURIText(param, text)
+}
+
+// validateURIText check URI parameter text extracted from request path
+func (v *validator) validateURIText(param parser.Property, text string) {
+	v.validateText(locationURI, "/"+pointerEscape(param.Name), param.APIType, text)
+}
+
+// validateBody check request or response body
+func (v *validator) validateBody(location string, apiType parser.APIType, body parser.Value) {
+	v.validateValue(location, "", apiType, body)
+}
+
+// validateValue check headers, query parameters, URI parameters and bodies,
+// violations are reported with pointer by validator, then value is checked by RAML parser
+func (v *validator) validateValue(location string, pointer string, apiType parser.APIType, value interface{}) {
+	generic := value
+	if parsed, ok := value.(parser.Value); ok {
+		generic = valueToInterface(parsed)
+	}
+	count := len(v.violations)
+	v.validate(location, pointer, apiType, generic, 0)
+	if len(v.violations) > count {
+		return
+	}
+	v.validateByParser(location, pointer, apiType, value)
+}
+
+// validateByParser check value by RAML parser to catch facets not supported by validator
+func (v *validator) validateByParser(location string, pointer string, apiType parser.APIType, value interface{}) {
+	if err := checkValueType(apiType, value, v.config.checkValueOptions()...); err != nil {
+		v.add(location, pointer, typeExpression(apiType), value, err.Error())
+	}
+}
+
+// validate check generic JSON value with apiType
+func (v *validator) validate(location string, pointer string, apiType parser.APIType, value interface{}, depth int) {
+	if depth > validateMaxDepth {
+		return
+	}
+
+	if len(apiType.Enum.Array) > 0 && !v.inEnum(apiType.Enum, value) {
+		v.add(location, pointer, "enum "+enumText(apiType.Enum), value, "value is not one of enum")
+		return
+	}
+
+	expr := typeExpression(apiType)
+	members := splitUnionTypeExpression(expr)
+	switch {
+	case len(members) > 1:
+		for _, member := range members {
+			memberType := parser.APIType{}
+			memberType.Type = member
+			sub := newValidator(v.types, v.config)
+			sub.validate(location, pointer, memberType, value, depth+1)
+			if len(sub.violations) < 1 {
+				return
+			}
+		}
+		v.add(location, pointer, expr, value, "value does not match any member of union type")
+		return
+	case strings.HasSuffix(expr, "[]"):
+		item := parser.APIType{}
+		item.Type = trimTypeExpression(strings.TrimSuffix(expr, "[]"))
+		v.validateArray(location, pointer, apiType, item, value, depth)
+		return
+	case expr == "":
+		if len(apiType.Properties.Slice()) > 0 {
+			v.validateObject(location, pointer, apiType, value, depth)
+			return
+		}
+		if apiType.Items != nil {
+			v.validateArray(location, pointer, apiType, *apiType.Items, value, depth)
+			return
+		}
+		if isTextValue(value) {
+			v.validateString(location, pointer, apiType, value)
+		}
+		return
+	}
+
+	switch expr {
+	case parser.TypeObject:
+		v.validateObject(location, pointer, apiType, value, depth)
+	case parser.TypeArray:
+		item := parser.APIType{}
+		if apiType.Items != nil {
+			item = *apiType.Items
+		}
+		v.validateArray(location, pointer, apiType, item, value, depth)
+	case parser.TypeString:
+		v.validateString(location, pointer, apiType, value)
+	case parser.TypeInteger, parser.TypeNumber:
+		v.validateNumber(location, pointer, apiType, expr, value)
+	case parser.TypeBoolean:
+		if _, ok := value.(bool); !ok {
+			v.add(location, pointer, expr, value, "value is not boolean")
+		}
+	case typeDateOnly, typeTimeOnly, typeDateTimeOnly, typeDateTime:
+		v.validateDateTime(location, pointer, apiType, expr, value)
+	case typeNil:
+		if value != nil {
+			v.add(location, pointer, expr, value, "value is not nil")
+		}
+	case typeAny, typeFile:
+	default:
+		parent, exist := v.types[expr]
+		if !exist || parent == nil {
+			errutil.Trace(ErrorTypeNotFound1.New(nil, expr))
+			return
+		}
+		if isObjectType(v.types, apiType) {
+			v.validateObject(location, pointer, apiType, value, depth)
+			return
+		}
+		v.validate(location, pointer, inheritAPIType(apiType, *parent), value, depth+1)
+	}
+}
+
+func (v *validator) validateObject(location string, pointer string, apiType parser.APIType, value interface{}, depth int) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		v.add(location, pointer, parser.TypeObject, value, "value is not object")
+		return
+	}
+	for _, property := range objectProperties(v.types, apiType) {
+		propPointer := pointer + "/" + pointerEscape(property.Name)
+		propValue, exist := object[property.Name]
+		if !exist {
+			if property.Required {
+				v.add(location, propPointer, "required", nil, fmt.Sprintf("property %q required", property.Name))
+			}
+			continue
+		}
+		if property.Required && isEmptyValue(propValue) && !v.config.AllowRequiredPropertyToBeEmpty {
+			v.add(location, propPointer, "required", propValue, fmt.Sprintf("required property %q is empty", property.Name))
+			continue
+		}
+		if propValue == nil && !property.Required {
+			continue
+		}
+		v.validate(location, propPointer, property.APIType, propValue, depth+1)
+	}
+}
+
+func (v *validator) validateArray(location string, pointer string, apiType parser.APIType, item parser.APIType, value interface{}, depth int) {
+	items, ok := value.([]interface{})
+	if !ok {
+		v.add(location, pointer, parser.TypeArray, value, "value is not array")
+		return
+	}
+	if apiType.MinItems > 0 && int64(len(items)) < apiType.MinItems {
+		v.add(location, pointer, fmt.Sprintf("minItems %d", apiType.MinItems), len(items), "too few items")
+	}
+	if apiType.MaxItems > 0 && int64(len(items)) > apiType.MaxItems {
+		v.add(location, pointer, fmt.Sprintf("maxItems %d", apiType.MaxItems), len(items), "too many items")
+	}
+	if apiType.UniqueItems {
+		seen := map[string]bool{}
+		for _, itemValue := range items {
+			key, _ := json.Marshal(itemValue)
+			if seen[string(key)] {
+				v.add(location, pointer, "uniqueItems", itemValue, "duplicated item")
+				break
+			}
+			seen[string(key)] = true
+		}
+	}
+	for i, itemValue := range items {
+		v.validate(location, fmt.Sprintf("%s/%d", pointer, i), item, itemValue, depth+1)
+	}
+}
+
+func (v *validator) validateString(location string, pointer string, apiType parser.APIType, value interface{}) {
+	text, ok := value.(string)
+	if !ok {
+		v.add(location, pointer, parser.TypeString, value, "value is not string")
+		return
+	}
+	length := int64(len([]rune(text)))
+	if apiType.MinLength > 0 && length < apiType.MinLength {
+		v.add(location, pointer, fmt.Sprintf("minLength %d", apiType.MinLength), value, "string too short")
+	}
+	if apiType.MaxLength > 0 && length > apiType.MaxLength {
+		v.add(location, pointer, fmt.Sprintf("maxLength %d", apiType.MaxLength), value, "string too long")
+	}
+	if apiType.Pattern != "" {
+		pattern, err := regexp.Compile(apiType.Pattern)
+		if err != nil {
+			errutil.Trace(err)
+			return
+		}
+		if !pattern.MatchString(text) {
+			v.add(location, pointer, "pattern "+apiType.Pattern, value, "string does not match pattern")
+		}
+	}
+}
+
+func (v *validator) validateNumber(location string, pointer string, apiType parser.APIType, expr string, value interface{}) {
+	var number float64
+	switch num := value.(type) {
+	case float64:
+		number = num
+	case int64:
+		number = float64(num)
+	case int:
+		number = float64(num)
+	default:
+		v.add(location, pointer, expr, value, "value is not "+expr)
+		return
+	}
+	if expr == parser.TypeInteger && number != math.Trunc(number) {
+		v.add(location, pointer, expr, value, "value is not integer")
+		return
+	}
+	if apiType.Minimum != nil && number < *apiType.Minimum {
+		v.add(location, pointer, fmt.Sprintf("minimum %v", *apiType.Minimum), value, "number too small")
+	}
+	if apiType.Maximum != nil && number > *apiType.Maximum {
+		v.add(location, pointer, fmt.Sprintf("maximum %v", *apiType.Maximum), value, "number too large")
+	}
+	if apiType.MultipleOf > 0 {
+		if quotient := number / apiType.MultipleOf; quotient != math.Trunc(quotient) {
+			v.add(location, pointer, fmt.Sprintf("multipleOf %v", apiType.MultipleOf), value, "number is not multiple")
+		}
+	}
+}
+
+func (v *validator) validateDateTime(location string, pointer string, apiType parser.APIType, expr string, value interface{}) {
+	text, ok := value.(string)
+	if !ok {
+		v.add(location, pointer, expr, value, "value is not "+expr)
+		return
+	}
+	layout := ""
+	switch expr {
+	case typeDateOnly:
+		layout = "2006-01-02"
+	case typeTimeOnly:
+		layout = "15:04:05"
+	case typeDateTimeOnly:
+		layout = "2006-01-02T15:04:05"
+	case typeDateTime:
+		layout = time.RFC3339
+		if strings.ToLower(apiType.Format) == "rfc2616" {
+			layout = http.TimeFormat
+		}
+	}
+	if _, err := time.Parse(layout, text); err != nil {
+		v.add(location, pointer, expr, value, "value is not "+expr)
+	}
+}
+
+func (v *validator) inEnum(enum parser.Value, value interface{}) bool {
+	for _, item := range enum.Array {
+		if item != nil && formatText(valueToInterface(*item)) == formatText(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumText(enum parser.Value) string {
+	data, err := json.Marshal(valueToInterface(enum))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func isTextValue(value interface{}) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+// isEmptyValue return true if value is nil or empty string
+func isEmptyValue(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	}
+	return false
+}
+
+// abortValidationFailed write all violations in JSON or RFC 7807 problem+json if client prefers it,
+// response with 404 if URI parameter is invalid since the URI does not identify any declared resource
+func abortValidationFailed(c *gin.Context, violations []violation) {
+	c.Set(contextKeyViolations, violations)
+	err := ErrorValidationFailed.New(nil)
+	code := http.StatusBadRequest
+	for _, item := range violations {
+		if item.Location == locationURI {
+			code = http.StatusNotFound
+			break
+		}
+	}
+
+	mimetype, _ := negotiateMIMEType(c.Request.Header.Get("Accept"), []string{mimeTypeJSON, mimeTypeProblemJSON})
+	if mimetype == mimeTypeProblemJSON {
+		outputJSON(c, code, mimeTypeProblemJSON, parser.APIType{}, nil, gin.H{
+			"type":       "about:blank",
+			"title":      err.Error(),
+			"status":     code,
+			"violations": violations,
+		})
+	} else {
+		outputJSON(c, code, mimeTypeJSON, parser.APIType{}, nil, gin.H{
+			"error":      err.Error(),
+			"violations": violations,
+		})
+	}
+	c.Abort()
+}
@@ -1,53 +1,311 @@
 package mocker
 
 import (
+	"io/ioutil"
 	"os"
-	"os/signal"
 	"path/filepath"
-	"syscall"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/tsaikd/KDGoLib/errutil"
+	"github.com/tsaikd/KDGoLib/futil"
 	"gopkg.in/fsnotify.v1"
 )
 
-func watch(dir string) {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGTERM, syscall.SIGINT)
-	go func() {
-		watcher, err := fsnotify.NewWatcher()
-		if err != nil {
-			errutil.Trace(err)
+// editors generate several events on saving file, reload once after events settled
+var watchDebounce = 300 * time.Millisecond
+
+var (
+	regRAMLInclude = regexp.MustCompile(`!include\s+([^\s"',}\]]+)`)
+	regRAMLExtends = regexp.MustCompile(`^extends:\s*(\S+)`)
+	regRAMLUses    = regexp.MustCompile(`^uses:\s*$`)
+	regRAMLLibrary = regexp.MustCompile(`^\s+[\w-]+:\s*(\S+)`)
+	regJSONRef     = regexp.MustCompile(`"\$ref"\s*:\s*"([^"#]+)`)
+)
+
+// ramlDependencies return absolute paths of RAML file and files referenced by it recursively,
+// including !include files, libraries in uses, extended RAML file of overlays and extensions
+// and JSON schema $ref files, referenced file not existed is still returned to be watched
+func ramlDependencies(ramlFile string) []string {
+	files := []string{}
+	visited := map[string]bool{}
+	var visit func(path string)
+	visit = func(path string) {
+		path, err := filepath.Abs(path)
+		if err != nil || visited[path] {
 			return
 		}
-		err = filepath.Walk(dir, func(fPath string, info os.FileInfo, ferr error) error {
-			if ferr != nil {
-				return ferr
+		visited[path] = true
+		files = append(files, path)
+		for _, ref := range ramlReferences(path) {
+			if isRemoteReference(ref) {
+				continue
 			}
-			if info.IsDir() {
-				logger.Debugf("start watching %q", fPath)
-				return watcher.Add(fPath)
+			if !filepath.IsAbs(ref) {
+				ref = filepath.Join(filepath.Dir(path), ref)
 			}
-			return nil
-		})
-		if err != nil {
-			errutil.Trace(err)
-			return
+			visit(ref)
+		}
+	}
+	visit(ramlFile)
+	return files
+}
+
+func isRemoteReference(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")
+}
+
+// ramlReferences return file references declared in file, !include and JSON schema $ref are scanned in any text file,
+// uses and extends are scanned in RAML and YAML files only
+func ramlReferences(path string) []string {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil || !utf8.Valid(raw) {
+		return nil
+	}
+	isRAML := false
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".raml", ".yaml", ".yml":
+		isRAML = true
+	}
+
+	refs := []string{}
+	inUses := false
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		for _, matches := range regRAMLInclude.FindAllStringSubmatch(line, -1) {
+			refs = append(refs, matches[1])
+		}
+		for _, matches := range regJSONRef.FindAllStringSubmatch(line, -1) {
+			refs = append(refs, matches[1])
+		}
+		if !isRAML {
+			continue
+		}
+		if matches := regRAMLExtends.FindStringSubmatch(line); matches != nil {
+			refs = append(refs, strings.Trim(matches[1], `"'`))
+		}
+
+		switch {
+		case regRAMLUses.MatchString(line):
+			inUses = true
+		case inUses && strings.TrimSpace(line) == "":
+		case inUses && regRAMLLibrary.MatchString(line):
+			if ref := strings.Trim(regRAMLLibrary.FindStringSubmatch(line)[1], `"'`); !strings.HasPrefix(ref, "!") {
+				refs = append(refs, ref)
+			}
+		default:
+			inUses = false
+		}
+	}
+	return refs
+}
+
+// fileWatcher reload RAML file when it or its dependencies changed,
+// whole directory tree is watched if RAML file is a directory
+type fileWatcher struct {
+	watcher    *fsnotify.Watcher
+	ramlFile   string
+	root       string
+	reloadFunc func() error
+
+	mutex sync.Mutex
+	files map[string]bool
+	dirs  map[string]bool
+	timer *time.Timer
+}
+
+// watch call reloadFunc after RAML file or its dependencies changed, until returned watcher is closed
+func watch(ramlFile string, reloadFunc func() error) (*fileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &fileWatcher{
+		watcher:    watcher,
+		ramlFile:   ramlFile,
+		reloadFunc: reloadFunc,
+		files:      map[string]bool{},
+		dirs:       map[string]bool{},
+	}
+	if futil.IsDir(ramlFile) {
+		if t.root, err = filepath.Abs(ramlFile); err != nil {
+			errutil.Trace(watcher.Close())
+			return nil, err
+		}
+		if err = t.watchTree(t.root); err != nil {
+			errutil.Trace(watcher.Close())
+			return nil, err
+		}
+	} else {
+		t.updateFiles()
+	}
+
+	go t.run()
+	return t, nil
+}
+
+// Close stop watching files
+func (t *fileWatcher) Close() error {
+	t.mutex.Lock()
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.mutex.Unlock()
+	return t.watcher.Close()
+}
+
+func (t *fileWatcher) addDir(dir string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.dirs[dir] {
+		return nil
+	}
+	logger.Debugf("start watching %q", dir)
+	if err := t.watcher.Add(dir); err != nil {
+		return err
+	}
+	t.dirs[dir] = true
+	return nil
+}
+
+// watchTree watch directory and its sub directories
+func (t *fileWatcher) watchTree(dir string) error {
+	return filepath.Walk(dir, func(fPath string, info os.FileInfo, ferr error) error {
+		if ferr != nil {
+			return ferr
+		}
+		if info.IsDir() {
+			return t.addDir(fPath)
 		}
+		return nil
+	})
+}
 
-		for {
-			select {
-			case <-c:
+// updateFiles watch directories of RAML file dependencies,
+// directories are watched instead of files to see atomic saves by renaming,
+// the nearest existing parent is watched instead if directory of dependency not existed yet
+func (t *fileWatcher) updateFiles() {
+	files := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, file := range ramlDependencies(t.ramlFile) {
+		files[file] = true
+		if dir := existingDir(filepath.Dir(file)); dir != "" {
+			dirs[dir] = true
+		}
+	}
+
+	for dir := range dirs {
+		errutil.Trace(t.addDir(dir))
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for dir := range t.dirs {
+		if !dirs[dir] {
+			logger.Debugf("stop watching %q", dir)
+			errutil.Trace(t.watcher.Remove(dir))
+			delete(t.dirs, dir)
+		}
+	}
+	t.files = files
+}
+
+// existingDir return dir or its nearest existing parent, empty if none existed
+func existingDir(dir string) string {
+	for !futil.IsDir(dir) {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+	return dir
+}
+
+// isRelevant return true if event path affects RAML document,
+// path of directory containing dependencies is relevant since dependencies may be created in it
+func (t *fileWatcher) isRelevant(path string) bool {
+	if t.root != "" {
+		return path == t.root || strings.HasPrefix(path, t.root+string(filepath.Separator))
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.files[path] {
+		return true
+	}
+	for file := range t.files {
+		if strings.HasPrefix(file, path+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *fileWatcher) run() {
+	for {
+		select {
+		case evt, ok := <-t.watcher.Events:
+			if !ok {
 				logger.Debugln("shutting down disk watcher ... done")
-				err = watcher.Close()
-				errutil.Trace(err)
-				os.Exit(0)
-			case evt := <-watcher.Events:
-				switch evt.Op {
-				case fsnotify.Create, fsnotify.Write:
-					logger.Debugln("reloading", evt.String())
-					errutil.Trace(reload())
-				}
+				return
 			}
+			t.handleEvent(evt)
+		case err, ok := <-t.watcher.Errors:
+			if !ok {
+				return
+			}
+			errutil.Trace(err)
 		}
-	}()
+	}
+}
+
+func (t *fileWatcher) handleEvent(evt fsnotify.Event) {
+	if evt.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Rename|fsnotify.Remove) == 0 {
+		return
+	}
+	path, err := filepath.Abs(evt.Name)
+	if err != nil || !t.isRelevant(path) {
+		return
+	}
+
+	if t.root != "" && evt.Op&fsnotify.Create != 0 && futil.IsDir(path) {
+		errutil.Trace(t.watchTree(path))
+	}
+	if t.root == "" && evt.Op&fsnotify.Create != 0 && futil.IsDir(path) {
+		// watch created directory of dependencies before files written in it
+		t.updateFiles()
+	}
+	if evt.Op&(fsnotify.Rename|fsnotify.Remove) != 0 {
+		// watch of removed directory is dropped by fsnotify
+		t.mutex.Lock()
+		delete(t.dirs, path)
+		t.mutex.Unlock()
+	}
+
+	logger.Debugln("file changed", evt.String())
+	t.scheduleReload()
+}
+
+// scheduleReload reload after no more events in debounce duration
+func (t *fileWatcher) scheduleReload() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.timer = time.AfterFunc(watchDebounce, t.reload)
+}
+
+func (t *fileWatcher) reload() {
+	logger.Debugln("reloading", t.ramlFile)
+	// failure is logged and kept in reload status
+	t.reloadFunc()
+	if t.root == "" {
+		// dependencies may be changed by new content
+		t.updateFiles()
+	}
 }
@@ -0,0 +1,375 @@
+package mocker
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/tsaikd/gin"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+const (
+	mimeTypeXML     = "application/xml"
+	mimeTypeTextXML = "text/xml"
+)
+
+// default XML element names if not declared by type or xml facet
+const (
+	xmlDefaultRootName = "root"
+	xmlDefaultItemName = "item"
+)
+
+// isXMLMIMEType return true if mimetype is XML or with +xml suffix
+func isXMLMIMEType(mimetype string) bool {
+	mimetype = strings.ToLower(mimetypeWithoutParams(mimetype))
+	return mimetype == mimeTypeXML || mimetype == mimeTypeTextXML || strings.HasSuffix(mimetype, "+xml")
+}
+
+// isXMLDocument return true if text looks like a XML document, e.g. example written in XML
+func isXMLDocument(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), "<")
+}
+
+func outputXML(c *gin.Context, code int, mimetype string, apiType parser.APIType, types parser.APITypes, data interface{}) {
+	value, ok := data.(parser.Value)
+	if !ok {
+		c.AbortWithError(http.StatusInternalServerError, ErrorUnsupportedOutputType1.New(nil, data))
+		return
+	}
+
+	if value.Type == parser.TypeString && isXMLDocument(value.String) {
+		c.Data(code, mimetype+"; charset=utf-8", []byte(value.String))
+		return
+	}
+
+	buffer := bytes.NewBufferString(xml.Header)
+	if err := encodeXML(buffer, types, apiType, valueToInterface(value), isPretty(c)); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Data(code, mimetype+"; charset=utf-8", buffer.Bytes())
+}
+
+// encodeXML write data as XML document, element names are decided by RAML xml facets
+func encodeXML(w io.Writer, types parser.APITypes, apiType parser.APIType, data interface{}, pretty bool) (err error) {
+	encoder := xml.NewEncoder(w)
+	if pretty {
+		encoder.Indent("", "\t")
+	}
+	if err = encodeXMLElement(encoder, types, apiType, xmlTypeName(types, apiType, xmlDefaultRootName), data); err != nil {
+		return
+	}
+	return encoder.Flush()
+}
+
+// xmlFacet return xml facet of type, facets not declared are inherited from parent type
+func xmlFacet(types parser.APITypes, apiType parser.APIType) parser.XML {
+	facet := apiType.XML
+	for i := 0; i < typeMaxInheritDepth; i++ {
+		parent, exist := types[typeExpression(apiType)]
+		if !exist || parent == nil {
+			break
+		}
+		apiType = *parent
+		if facet.Name == "" {
+			facet.Name = apiType.XML.Name
+		}
+		if facet.Namespace == "" {
+			facet.Namespace = apiType.XML.Namespace
+		}
+		if facet.Prefix == "" {
+			facet.Prefix = apiType.XML.Prefix
+		}
+	}
+	return facet
+}
+
+// xmlTypeName return XML element name of type, fallback if type is anonymous
+func xmlTypeName(types parser.APITypes, apiType parser.APIType, fallback string) string {
+	if name := xmlFacet(types, apiType).Name; name != "" {
+		return name
+	}
+	expr := typeExpression(apiType)
+	if expr != "" && !isBuiltinType(expr) && !strings.HasSuffix(expr, "[]") && len(splitUnionTypeExpression(expr)) < 2 {
+		return expr
+	}
+	if apiType.Name != "" {
+		return apiType.Name
+	}
+	return fallback
+}
+
+// xmlPropertyName return XML element or attribute name of property
+func xmlPropertyName(property parser.Property) string {
+	if property.XML.Name != "" {
+		return property.XML.Name
+	}
+	return property.Name
+}
+
+func xmlStartElement(facet parser.XML, name string) xml.StartElement {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if facet.Prefix != "" {
+		start.Name.Local = facet.Prefix + ":" + name
+		if facet.Namespace != "" {
+			start.Attr = append(start.Attr, xml.Attr{
+				Name:  xml.Name{Local: "xmlns:" + facet.Prefix},
+				Value: facet.Namespace,
+			})
+		}
+	} else if facet.Namespace != "" {
+		start.Attr = append(start.Attr, xml.Attr{
+			Name:  xml.Name{Local: "xmlns"},
+			Value: facet.Namespace,
+		})
+	}
+	return start
+}
+
+func encodeXMLElement(encoder *xml.Encoder, types parser.APITypes, apiType parser.APIType, name string, data interface{}) (err error) {
+	start := xmlStartElement(xmlFacet(types, apiType), name)
+
+	switch value := data.(type) {
+	case map[string]interface{}:
+		properties := objectProperties(types, apiType)
+		declared := map[string]bool{}
+		elements := []*parser.Property{}
+		for _, property := range properties {
+			declared[property.Name] = true
+			propValue, exist := value[property.Name]
+			if !exist {
+				continue
+			}
+			if property.XML.Attribute {
+				start.Attr = append(start.Attr, xml.Attr{
+					Name:  xml.Name{Local: xmlPropertyName(*property)},
+					Value: formatText(propValue),
+				})
+				continue
+			}
+			elements = append(elements, property)
+		}
+
+		if err = encoder.EncodeToken(start); err != nil {
+			return
+		}
+		for _, property := range elements {
+			if err = encodeXMLProperty(encoder, types, *property, value[property.Name]); err != nil {
+				return
+			}
+		}
+
+		undeclared := []string{}
+		for key := range value {
+			if !declared[key] {
+				undeclared = append(undeclared, key)
+			}
+		}
+		sort.Strings(undeclared)
+		for _, key := range undeclared {
+			if err = encodeXMLElement(encoder, types, parser.APIType{}, key, value[key]); err != nil {
+				return
+			}
+		}
+	case []interface{}:
+		item := arrayItemType(types, apiType)
+		itemName := xmlTypeName(types, item, xmlDefaultItemName)
+		if err = encoder.EncodeToken(start); err != nil {
+			return
+		}
+		for _, itemValue := range value {
+			if err = encodeXMLElement(encoder, types, item, itemName, itemValue); err != nil {
+				return
+			}
+		}
+	case nil:
+		if err = encoder.EncodeToken(start); err != nil {
+			return
+		}
+	default:
+		if err = encoder.EncodeToken(start); err != nil {
+			return
+		}
+		if err = encoder.EncodeToken(xml.CharData(formatText(value))); err != nil {
+			return
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+// encodeXMLProperty write property as XML element,
+// array property is written as repeated elements unless it is wrapped
+func encodeXMLProperty(encoder *xml.Encoder, types parser.APITypes, property parser.Property, data interface{}) (err error) {
+	name := xmlPropertyName(property)
+	items, isArray := data.([]interface{})
+	if !isArray || property.XML.Wrapped {
+		return encodeXMLElement(encoder, types, property.APIType, name, data)
+	}
+
+	item := arrayItemType(types, property.APIType)
+	for _, itemValue := range items {
+		if err = encodeXMLElement(encoder, types, item, name, itemValue); err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// xmlNode is a generic XML element tree
+type xmlNode struct {
+	name     string
+	attrs    map[string]string
+	children []*xmlNode
+	text     string
+}
+
+// childrenByName return child elements with local name
+func (t xmlNode) childrenByName(name string) []*xmlNode {
+	children := []*xmlNode{}
+	for _, child := range t.children {
+		if child.name == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// decodeXML return root element of XML document
+func decodeXML(r io.Reader) (*xmlNode, error) {
+	decoder := xml.NewDecoder(r)
+	var root *xmlNode
+	stack := []*xmlNode{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF && root != nil {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{
+				name:  element.Name.Local,
+				attrs: map[string]string{},
+			}
+			for _, attr := range element.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				node.attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) < 1 && root != nil {
+				return root, nil
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(element)
+			}
+		}
+	}
+}
+
+// xmlNodeValue convert XML element to generic value guided by RAML type declaration
+func xmlNodeValue(types parser.APITypes, apiType parser.APIType, node *xmlNode) interface{} {
+	switch baseTypeOf(types, apiType) {
+	case parser.TypeObject:
+		result := map[string]interface{}{}
+		declared := map[string]bool{}
+		for _, property := range objectProperties(types, apiType) {
+			name := xmlPropertyName(*property)
+			declared[name] = true
+			if property.XML.Attribute {
+				if attr, exist := node.attrs[name]; exist {
+					result[property.Name] = scalarValue(types, property.APIType, attr)
+				}
+				continue
+			}
+
+			children := node.childrenByName(name)
+			if len(children) < 1 {
+				continue
+			}
+			if baseTypeOf(types, property.APIType) != parser.TypeArray {
+				result[property.Name] = xmlNodeValue(types, property.APIType, children[0])
+				continue
+			}
+			if property.XML.Wrapped {
+				children = children[0].children
+			}
+			item := arrayItemType(types, property.APIType)
+			items := []interface{}{}
+			for _, child := range children {
+				items = append(items, xmlNodeValue(types, item, child))
+			}
+			result[property.Name] = items
+		}
+		for name, attr := range node.attrs {
+			if !declared[name] {
+				result[name] = attr
+			}
+		}
+		setUntypedChildren(types, result, node.children, declared)
+		return result
+	case parser.TypeArray:
+		item := arrayItemType(types, apiType)
+		items := []interface{}{}
+		for _, child := range node.children {
+			items = append(items, xmlNodeValue(types, item, child))
+		}
+		return items
+	case "":
+		if len(node.children) < 1 && len(node.attrs) < 1 {
+			return node.text
+		}
+		result := map[string]interface{}{}
+		for name, attr := range node.attrs {
+			result[name] = attr
+		}
+		setUntypedChildren(types, result, node.children, nil)
+		return result
+	default:
+		return scalarValue(types, apiType, node.text)
+	}
+}
+
+// setUntypedChildren set values of child elements not skipped into result,
+// element repeated in siblings is set as list of element values, whatever the values are
+func setUntypedChildren(types parser.APITypes, result map[string]interface{}, children []*xmlNode, skip map[string]bool) {
+	counts := map[string]int{}
+	for _, child := range children {
+		counts[child.name]++
+	}
+	repeated := map[string][]interface{}{}
+	for _, child := range children {
+		if skip[child.name] {
+			continue
+		}
+		value := xmlNodeValue(types, parser.APIType{}, child)
+		if counts[child.name] > 1 {
+			repeated[child.name] = append(repeated[child.name], value)
+			continue
+		}
+		result[child.name] = value
+	}
+	for name, values := range repeated {
+		result[name] = values
+	}
+}
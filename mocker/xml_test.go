@@ -0,0 +1,32 @@
+package mocker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsaikd/go-raml-parser/parser"
+)
+
+func Test_xmlNodeValue(t *testing.T) {
+	require := require.New(t)
+	require.NotNil(require)
+
+	node, err := decodeXML(strings.NewReader(`<order id="7">
+	<item><tag>a</tag><tag>b</tag></item>
+	<item><tag>c</tag><tag>d</tag></item>
+	<note><line>x</line><line>y</line></note>
+</order>`))
+	require.NoError(err)
+
+	// test repeated elements containing lists are kept as list of elements
+	value := xmlNodeValue(parser.APITypes{}, parser.APIType{}, node)
+	require.Equal(map[string]interface{}{
+		"id": "7",
+		"item": []interface{}{
+			map[string]interface{}{"tag": []interface{}{"a", "b"}},
+			map[string]interface{}{"tag": []interface{}{"c", "d"}},
+		},
+		"note": map[string]interface{}{"line": []interface{}{"x", "y"}},
+	}, value)
+}